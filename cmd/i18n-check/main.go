@@ -0,0 +1,76 @@
+// Command i18n-check 检查 i18n/{lang}/ 目录下各语言相对参考语言的翻译覆盖率
+//
+// 用法：
+//
+//	go run github.com/gaoyong06/go-pkg/cmd/i18n-check -dir ./configs/i18n -ref zh-CN
+//	go run github.com/gaoyong06/go-pkg/cmd/i18n-check -dir ./configs/i18n -skeleton ./i18n-todo
+//
+// 报告缺失/多余的键、模板变量不一致、复数类别不符合目标语言规则的消息以及无法解析的翻译文件，
+// 存在问题时以状态码 1 退出，便于在 CI 中使用。
+// 指定 -skeleton 时，会在该目录下生成 {lang}/{file}.json 骨架文件，内容为参考语言原文。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gaoyong06/go-pkg/middleware/i18n"
+)
+
+func main() {
+	dir := flag.String("dir", "i18n", "i18n 根目录，包含 {lang}/ 子目录")
+	ref := flag.String("ref", "zh-CN", "参考语言")
+	skeletonDir := flag.String("skeleton", "", "骨架文件输出目录，为空时不生成")
+	flag.Parse()
+
+	report, err := i18n.CheckCoverage(os.DirFS(*dir), ".", *ref)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "i18n-check: %v\n", err)
+		os.Exit(2)
+	}
+
+	for _, issue := range report.Issues {
+		fmt.Println(issue.String())
+	}
+
+	if *skeletonDir != "" {
+		if err := writeSkeletons(report, *skeletonDir); err != nil {
+			fmt.Fprintf(os.Stderr, "i18n-check: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	fmt.Printf("reference %s, languages %v, %d issue(s)\n", report.Reference, report.Languages, len(report.Issues))
+	if report.HasIssues() {
+		os.Exit(1)
+	}
+}
+
+// writeSkeletons 将骨架文件写入 dir
+func writeSkeletons(report *i18n.CoverageReport, dir string) error {
+	files, err := report.Skeletons()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			return fmt.Errorf("create skeleton dir: %w", err)
+		}
+		if err := os.WriteFile(filePath, files[name], 0o644); err != nil {
+			return fmt.Errorf("write skeleton %s: %w", filePath, err)
+		}
+		fmt.Printf("skeleton written: %s\n", filePath)
+	}
+	return nil
+}
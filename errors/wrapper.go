@@ -53,6 +53,28 @@ func (m *ErrorManager) NewBizErrorWithLang(ctx context.Context, code int32) *kra
 	return m.NewBizError(code, lang)
 }
 
+// Messages 内置错误文案：语言 -> 错误码 -> 文案
+// 供中间件在未配置 ErrorManager 时使用，缺失的语言或错误码回退到 zh-CN
+type Messages map[string]map[int32]string
+
+// Get 获取错误文案，lang 不存在或该语言缺少错误码时使用 zh-CN 文案
+func (m Messages) Get(lang string, code int32) string {
+	if message, ok := m[lang][code]; ok {
+		return message
+	}
+	return m["zh-CN"][code]
+}
+
+// NewBizErrorWithFallback 创建本地化的业务错误
+// manager 不为 nil 时使用 manager.NewBizErrorWithLang（文案来自服务的 errors.json），
+// 否则按 lang 从内置文案 fallback 中获取
+func NewBizErrorWithFallback(ctx context.Context, manager *ErrorManager, fallback Messages, lang string, code int32) *kratosErrors.Error {
+	if manager != nil {
+		return manager.NewBizErrorWithLang(ctx, code)
+	}
+	return kratosErrors.New(int(code), "BIZ_ERROR", fallback.Get(lang, code))
+}
+
 // WrapError 包装错误为业务错误
 // err: 原始错误
 // code: 错误码
@@ -0,0 +1,33 @@
+package errors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// staticLoader 固定文案的错误消息加载器
+type staticLoader map[string]string
+
+func (l staticLoader) GetMessage(lang string, code int32) string { return l[lang] }
+
+func TestNewBizErrorWithFallback(t *testing.T) {
+	fallback := Messages{
+		"zh-CN": {ErrCodeForbidden: "没有权限", ErrCodeUnauthorized: "请先登录"},
+		"en-US": {ErrCodeForbidden: "Forbidden"},
+	}
+	ctx := context.Background()
+
+	err := NewBizErrorWithFallback(ctx, nil, fallback, "en-US", ErrCodeForbidden)
+	assert.Equal(t, int32(ErrCodeForbidden), err.Code)
+	assert.Equal(t, "Forbidden", err.Message)
+
+	// 缺少语言或错误码时回退到 zh-CN
+	assert.Equal(t, "没有权限", NewBizErrorWithFallback(ctx, nil, fallback, "ja-JP", ErrCodeForbidden).Message)
+	assert.Equal(t, "请先登录", NewBizErrorWithFallback(ctx, nil, fallback, "en-US", ErrCodeUnauthorized).Message)
+
+	// 配置 ErrorManager 时优先使用
+	manager := NewErrorManager(staticLoader{"zh-CN": "来自 errors.json"}, nil)
+	assert.Equal(t, "来自 errors.json", NewBizErrorWithFallback(ctx, manager, fallback, "en-US", ErrCodeForbidden).Message)
+}
@@ -0,0 +1,92 @@
+// Package lru 提供带过期时间、容量有限的 LRU 缓存，供中间件的本地缓存共用
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache 带过期时间的 LRU 缓存，并发安全
+// 超出容量时淘汰最久未使用的条目，过期条目在读取时删除
+type Cache[V any] struct {
+	mutex    sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+// entry 缓存条目
+type entry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// New 创建 LRU 缓存，capacity 为最大条目数
+func New[V any](capacity int) *Cache[V] {
+	return &Cache[V]{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get 获取未过期的缓存条目
+func (c *Cache[V]) Get(key string, now time.Time) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	e := elem.Value.(*entry[V])
+	if !now.Before(e.expiresAt) {
+		c.ll.Remove(elem)
+		delete(c.items, key)
+		return zero, false
+	}
+	c.ll.MoveToFront(elem)
+	return e.value, true
+}
+
+// Set 写入缓存条目，超出容量时淘汰最久未使用的条目
+func (c *Cache[V]) Set(key string, value V, expiresAt time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry[V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&entry[V]{key: key, value: value, expiresAt: expiresAt})
+	for c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry[V]).key)
+	}
+}
+
+// Delete 删除缓存条目
+func (c *Cache[V]) Delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.ll.Remove(elem)
+		delete(c.items, key)
+	}
+}
+
+// Len 当前条目数（包括尚未清理的过期条目）
+func (c *Cache[V]) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.ll.Len()
+}
@@ -0,0 +1,194 @@
+// Package app_id 提供 appId 中间件和工具函数
+package app_id
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gaoyong06/go-pkg/internal/lru"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// AppCacheConfig 应用信息缓存配置
+type AppCacheConfig struct {
+	// 本地缓存最大条目数，默认 10000；不存在的应用也占用条目，超出后淘汰最久未使用的条目
+	Size int `json:"size" yaml:"size"`
+
+	// 应用信息缓存时间，默认 5 分钟
+	TTL time.Duration `json:"ttl" yaml:"ttl"`
+
+	// 不存在的应用缓存时间，默认 1 分钟，避免被伪造的 appId 反复查询注册表
+	NotFoundTTL time.Duration `json:"not_found_ttl" yaml:"not_found_ttl"`
+
+	// Redis 键前缀，默认 "app:info:"
+	RedisKeyPrefix string `json:"redis_key_prefix" yaml:"redis_key_prefix"`
+}
+
+const (
+	defaultAppCacheSize      = 10000
+	defaultAppCacheTTL       = 5 * time.Minute
+	defaultAppNotFoundTTL    = time.Minute
+	defaultAppRedisKeyPrefix = "app:info:"
+)
+
+// timeNow 当前时间，测试时可替换
+var timeNow = time.Now
+
+// cachedApp 缓存的查询结果，App 为 nil 表示应用不存在
+type cachedApp struct {
+	App       *AppInfo  `json:"app,omitempty"`
+	ExpiresAt time.Time `json:"-"`
+}
+
+// CachedAppValidator 带缓存的应用注册表，AppValidator 的装饰器
+// - 本地 LRU 缓存，按 TTL 过期，条目数有上限（伪造的 appId 会产生不存在的缓存条目，不能无限增长）
+// - 可选 Redis 共享缓存，多实例之间共享查询结果
+// - 不存在的应用同样缓存（较短 TTL），注册表故障不缓存
+// - 同一应用的并发查询通过 singleflight 合并为一次下游调用
+type CachedAppValidator struct {
+	next   AppValidator
+	config AppCacheConfig
+	rdb    *redis.Client
+	local  *lru.Cache[*cachedApp]
+	group  singleflight.Group
+	log    *log.Helper
+}
+
+// NewCachedAppValidator 创建带缓存的应用注册表
+// next: 实际的应用注册表，如应用管理服务的客户端
+// config: 缓存配置，为 nil 时使用默认配置
+// rdb: Redis 客户端，为 nil 时只使用本地缓存
+func NewCachedAppValidator(next AppValidator, config *AppCacheConfig, rdb *redis.Client, logger log.Logger) *CachedAppValidator {
+	conf := AppCacheConfig{}
+	if config != nil {
+		conf = *config
+	}
+	if conf.Size <= 0 {
+		conf.Size = defaultAppCacheSize
+	}
+	if conf.TTL <= 0 {
+		conf.TTL = defaultAppCacheTTL
+	}
+	if conf.NotFoundTTL <= 0 {
+		conf.NotFoundTTL = defaultAppNotFoundTTL
+	}
+	if conf.RedisKeyPrefix == "" {
+		conf.RedisKeyPrefix = defaultAppRedisKeyPrefix
+	}
+
+	return &CachedAppValidator{
+		next:   next,
+		config: conf,
+		rdb:    rdb,
+		local:  lru.New[*cachedApp](conf.Size),
+		log:    log.NewHelper(logger),
+	}
+}
+
+// GetApp 获取应用信息，优先使用缓存结果
+func (v *CachedAppValidator) GetApp(ctx context.Context, appID string) (*AppInfo, error) {
+	entry, ok := v.local.Get(appID, timeNow())
+	if !ok {
+		// 合并的调用共享第一个调用方的 ctx，去掉取消信号，避免一个调用方取消导致其他调用方一起失败
+		result, err, _ := v.group.Do(appID, func() (interface{}, error) {
+			return v.load(context.WithoutCancel(ctx), appID)
+		})
+		if err != nil {
+			return nil, err
+		}
+		entry = result.(*cachedApp)
+	}
+
+	if entry.App == nil {
+		return nil, ErrAppNotFound
+	}
+	return copyAppInfo(entry.App), nil
+}
+
+// load 缓存未命中时的查询流程：Redis 共享缓存 -> 下游注册表 -> 写回缓存
+func (v *CachedAppValidator) load(ctx context.Context, appID string) (*cachedApp, error) {
+	if v.rdb != nil {
+		if entry, ok := v.getShared(ctx, appID); ok {
+			v.setLocal(appID, entry)
+			return entry, nil
+		}
+	}
+
+	app, err := v.next.GetApp(ctx, appID)
+	if err != nil && !errors.Is(err, ErrAppNotFound) {
+		return nil, fmt.Errorf("get app failed: %w", err)
+	}
+
+	ttl := v.config.TTL
+	if app == nil {
+		ttl = v.config.NotFoundTTL
+	}
+	entry := &cachedApp{App: app, ExpiresAt: timeNow().Add(ttl)}
+	v.setLocal(appID, entry)
+	if v.rdb != nil {
+		v.setShared(ctx, appID, entry, ttl)
+	}
+	return entry, nil
+}
+
+// Invalidate 清除应用的本地和 Redis 缓存（如应用被停用或配置变更后）
+// 其他实例的本地缓存在 TTL 到期后刷新
+func (v *CachedAppValidator) Invalidate(ctx context.Context, appID string) error {
+	v.local.Delete(appID)
+
+	if v.rdb == nil {
+		return nil
+	}
+	if err := v.rdb.Del(ctx, v.config.RedisKeyPrefix+appID).Err(); err != nil {
+		return fmt.Errorf("invalidate app cache failed: %w", err)
+	}
+	return nil
+}
+
+// setLocal 写入本地缓存
+func (v *CachedAppValidator) setLocal(appID string, entry *cachedApp) {
+	v.local.Set(appID, entry, entry.ExpiresAt)
+}
+
+// getShared 读取 Redis 共享缓存，Redis 故障时视为未命中
+func (v *CachedAppValidator) getShared(ctx context.Context, appID string) (*cachedApp, bool) {
+	key := v.config.RedisKeyPrefix + appID
+	pipe := v.rdb.Pipeline()
+	getCmd := pipe.Get(ctx, key)
+	ttlCmd := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if !errors.Is(err, redis.Nil) {
+			v.log.Warnf("read app cache failed: %v", err)
+		}
+		return nil, false
+	}
+
+	ttl := ttlCmd.Val()
+	if ttl <= 0 {
+		return nil, false
+	}
+	entry := &cachedApp{}
+	if err := json.Unmarshal([]byte(getCmd.Val()), entry); err != nil {
+		v.log.Warnf("decode app cache failed: %v", err)
+		return nil, false
+	}
+	entry.ExpiresAt = timeNow().Add(ttl)
+	return entry, true
+}
+
+// setShared 写入 Redis 共享缓存，失败只记录日志
+func (v *CachedAppValidator) setShared(ctx context.Context, appID string, entry *cachedApp, ttl time.Duration) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		v.log.Warnf("encode app cache failed: %v", err)
+		return
+	}
+	if err := v.rdb.Set(ctx, v.config.RedisKeyPrefix+appID, data, ttl).Err(); err != nil {
+		v.log.Warnf("write app cache failed: %v", err)
+	}
+}
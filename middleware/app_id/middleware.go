@@ -5,6 +5,7 @@ import (
 	"context"
 	"strings"
 
+	"github.com/gaoyong06/go-pkg/middleware/idcheck"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
@@ -12,14 +13,40 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
+// Option appId 中间件选项
+type Option func(*options)
+
+// options appId 中间件选项
+type options struct {
+	checker *idcheck.Checker
+}
+
+// Require 启用 appId 必填和格式校验，校验失败时返回本地化的业务错误（语法见 idcheck 包）
+// 例如：Middleware(Require(&idcheck.Config{Required: true, SkipPaths: []string{"/health"}}))
+func Require(config *idcheck.Config, opts ...idcheck.Option) Option {
+	return func(o *options) {
+		o.checker = idcheck.New("app_id", config, opts...)
+	}
+}
+
 // Middleware appId 中间件，提取 appId 并存入 context
 // appId 提取优先级：
 // 1. HTTP Header X-App-Id（由 API Gateway 设置）
 // 2. gRPC metadata X-App-Id（服务间调用时传递）
-func Middleware() middleware.Middleware {
+// 这些来源都可以被绕过网关的客户端伪造，对外暴露的服务应在本中间件之前使用 trust.Middleware
+// 默认不要求必须携带 appId，需要时使用 Require 选项
+func Middleware(opts ...Option) middleware.Middleware {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
 			appID := extractAppID(ctx)
+			if err := o.checker.Check(ctx, appID); err != nil {
+				return nil, err
+			}
 			if appID != "" {
 				ctx = WithAppID(ctx, appID)
 				// 调试日志：记录成功提取的 appId
@@ -61,7 +88,6 @@ func extractAppID(ctx context.Context) string {
 		if req := httpTr.Request(); req != nil {
 			if reqURL := req.URL; reqURL != nil {
 				if appID := reqURL.Query().Get("appId"); appID != "" {
-					log.NewHelper(log.GetLogger()).Infof("app_id middleware: found appId in query string: %s", appID)
 					return strings.TrimSpace(appID)
 				}
 			}
@@ -80,9 +106,6 @@ func extractAppIDFromGRPCMetadata(ctx context.Context) string {
 		return ""
 	}
 
-	// Debug: print all metadata keys
-	log.NewHelper(log.GetLogger()).Infof("app_id middleware: gRPC metadata keys: %v", md)
-
 	// gRPC metadata 的 key 会被转换为小写，所以使用 "x-app-id"
 	values := md.Get("x-app-id")
 	if len(values) > 0 && values[0] != "" {
@@ -0,0 +1,127 @@
+// Package app_id 提供 appId 中间件和工具函数
+package app_id
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrAppNotFound 应用不存在
+var ErrAppNotFound = errors.New("app not found")
+
+// AppInfo 已注册应用的信息和配置
+type AppInfo struct {
+	AppID           string   `json:"app_id" yaml:"app_id"`
+	Name            string   `json:"name" yaml:"name"`
+	Disabled        bool     `json:"disabled" yaml:"disabled"`
+	AllowedOrigins  []string `json:"allowed_origins" yaml:"allowed_origins"`   // 允许的跨域来源，如 "https://app.example.com"
+	RateLimitTier   string   `json:"rate_limit_tier" yaml:"rate_limit_tier"`   // 限流等级，如 "free"、"pro"
+	DefaultLanguage string   `json:"default_language" yaml:"default_language"` // 请求未指定语言时使用，如 "en-US"
+	Timezone        string   `json:"timezone" yaml:"timezone"`                 // 请求未指定时区时使用，如 "Asia/Shanghai"
+}
+
+// OriginAllowed 是否允许指定的跨域来源，"*" 表示允许全部
+func (a *AppInfo) OriginAllowed(origin string) bool {
+	if a == nil || origin == "" {
+		return false
+	}
+	for _, o := range a.AllowedOrigins {
+		if o == "*" || o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// AppValidator 应用注册表接口
+// 生产环境通常由应用管理服务的客户端实现；应用不存在时返回 ErrAppNotFound
+// 返回的 AppInfo 可能处于停用状态，是否放行由调用方（如 ValidateApp 中间件）判断
+type AppValidator interface {
+	GetApp(ctx context.Context, appID string) (*AppInfo, error)
+}
+
+// MemoryAppRegistry 内存应用注册表，适用于测试、本地开发和应用数量固定的服务
+type MemoryAppRegistry struct {
+	mutex sync.RWMutex
+	apps  map[string]*AppInfo
+}
+
+// NewMemoryAppRegistry 创建内存应用注册表
+func NewMemoryAppRegistry(apps ...*AppInfo) *MemoryAppRegistry {
+	r := &MemoryAppRegistry{apps: make(map[string]*AppInfo, len(apps))}
+	for _, app := range apps {
+		r.Add(app)
+	}
+	return r
+}
+
+// LoadAppFile 从 YAML/JSON 文件加载内存应用注册表
+// 文件格式：
+//
+//	apps:
+//	  - app_id: app-1
+//	    name: Demo
+//	    allowed_origins: [https://demo.example.com]
+//	    rate_limit_tier: free
+//	    default_language: en-US
+//	    timezone: America/New_York
+func LoadAppFile(path string) (*MemoryAppRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read app file: %w", err)
+	}
+
+	var file struct {
+		Apps []*AppInfo `json:"apps" yaml:"apps"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse app file %s: %w", path, err)
+	}
+	for i, app := range file.Apps {
+		if app == nil || app.AppID == "" {
+			return nil, fmt.Errorf("parse app file %s: apps[%d] missing app_id", path, i)
+		}
+	}
+	return NewMemoryAppRegistry(file.Apps...), nil
+}
+
+// Add 添加或替换应用
+func (r *MemoryAppRegistry) Add(app *AppInfo) {
+	if app == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.apps[app.AppID] = app
+}
+
+// Remove 删除应用
+func (r *MemoryAppRegistry) Remove(appID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.apps, appID)
+}
+
+// GetApp 获取应用信息
+func (r *MemoryAppRegistry) GetApp(ctx context.Context, appID string) (*AppInfo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	app, ok := r.apps[appID]
+	if !ok {
+		return nil, ErrAppNotFound
+	}
+	return copyAppInfo(app), nil
+}
+
+// copyAppInfo 复制应用信息，避免调用方修改注册表中的数据
+func copyAppInfo(app *AppInfo) *AppInfo {
+	c := *app
+	c.AllowedOrigins = append([]string(nil), app.AllowedOrigins...)
+	return &c
+}
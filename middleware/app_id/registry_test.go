@@ -0,0 +1,190 @@
+package app_id_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/app_id"
+	"github.com/gaoyong06/go-pkg/middleware/auth/authtest"
+	"github.com/gaoyong06/go-pkg/middleware/i18n"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingValidator 记录下游查询次数的注册表
+type countingValidator struct {
+	next  app_id.AppValidator
+	calls atomic.Int32
+	err   error
+}
+
+func (v *countingValidator) GetApp(ctx context.Context, appID string) (*app_id.AppInfo, error) {
+	v.calls.Add(1)
+	if v.err != nil {
+		return nil, v.err
+	}
+	return v.next.GetApp(ctx, appID)
+}
+
+func TestLoadAppFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apps.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+apps:
+  - app_id: app-1
+    name: Demo
+    allowed_origins: [https://demo.example.com]
+    rate_limit_tier: pro
+    default_language: en-US
+  - app_id: app-2
+    disabled: true
+`), 0o600))
+
+	registry, err := app_id.LoadAppFile(path)
+	require.NoError(t, err)
+
+	app, err := registry.GetApp(context.Background(), "app-1")
+	require.NoError(t, err)
+	assert.Equal(t, "pro", app.RateLimitTier)
+	assert.True(t, app.OriginAllowed("https://demo.example.com"))
+	assert.False(t, app.OriginAllowed("https://evil.example.com"))
+
+	_, err = registry.GetApp(context.Background(), "app-3")
+	assert.ErrorIs(t, err, app_id.ErrAppNotFound)
+
+	require.NoError(t, os.WriteFile(path, []byte("apps:\n  - name: missing id\n"), 0o600))
+	_, err = app_id.LoadAppFile(path)
+	assert.Error(t, err)
+}
+
+func TestCachedAppValidator(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	registry := app_id.NewMemoryAppRegistry(&app_id.AppInfo{AppID: "app-1", Name: "Demo"})
+	next := &countingValidator{next: registry}
+
+	cached := app_id.NewCachedAppValidator(next, nil, rdb, log.DefaultLogger)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		app, err := cached.GetApp(ctx, "app-1")
+		require.NoError(t, err)
+		assert.Equal(t, "Demo", app.Name)
+		_, err = cached.GetApp(ctx, "unknown")
+		assert.ErrorIs(t, err, app_id.ErrAppNotFound)
+	}
+	assert.Equal(t, int32(2), next.calls.Load(), "found and not-found results are both cached")
+	assert.True(t, mr.Exists("app:info:app-1"))
+	assert.True(t, mr.Exists("app:info:unknown"))
+
+	// 其他实例通过 Redis 共享缓存命中
+	other := app_id.NewCachedAppValidator(next, nil, rdb, log.DefaultLogger)
+	_, err := other.GetApp(ctx, "app-1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), next.calls.Load())
+
+	// 配置变更后清除缓存
+	registry.Add(&app_id.AppInfo{AppID: "app-1", Name: "Renamed"})
+	require.NoError(t, cached.Invalidate(ctx, "app-1"))
+	app, err := cached.GetApp(ctx, "app-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", app.Name)
+
+	// 注册表故障不缓存
+	next.err = errors.New("connection refused")
+	_, err = cached.GetApp(ctx, "app-2")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, app_id.ErrAppNotFound)
+	assert.False(t, mr.Exists("app:info:app-2"))
+}
+
+func TestCachedAppValidatorBounded(t *testing.T) {
+	next := &countingValidator{next: app_id.NewMemoryAppRegistry()}
+	cached := app_id.NewCachedAppValidator(next, &app_id.AppCacheConfig{Size: 2}, nil, log.DefaultLogger)
+	ctx := context.Background()
+
+	// 伪造的 appId 不会让本地缓存无限增长，超出容量后淘汰最久未使用的条目
+	for _, appID := range []string{"forged-1", "forged-2", "forged-3", "forged-1"} {
+		_, err := cached.GetApp(ctx, appID)
+		assert.ErrorIs(t, err, app_id.ErrAppNotFound)
+	}
+	assert.Equal(t, int32(4), next.calls.Load())
+}
+
+func TestValidateApp(t *testing.T) {
+	registry := app_id.NewMemoryAppRegistry(
+		&app_id.AppInfo{AppID: "app-1", DefaultLanguage: "en-US", Timezone: "Asia/Tokyo", RateLimitTier: "pro"},
+		&app_id.AppInfo{AppID: "app-2", Disabled: true},
+	)
+	handler := app_id.ValidateApp(registry, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		app, ok := app_id.GetAppInfoFromContext(ctx)
+		require.True(t, ok)
+		return []string{app.RateLimitTier, i18n.Language(ctx), i18n.Timezone(ctx)}, nil
+	})
+	call := func(appID string, headers map[string]string) (interface{}, error) {
+		ctx := authtest.ServerContext(context.Background(), "/op", headers)
+		ctx = i18n.WithLanguage(ctx, "zh-CN")
+		if appID != "" {
+			ctx = app_id.WithAppID(ctx, appID)
+		}
+		return handler(ctx, nil)
+	}
+
+	out, err := call("app-1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pro", "en-US", "Asia/Tokyo"}, out)
+
+	// 请求指定的语言优先于应用默认语言
+	out, err = call("app-1", map[string]string{"Accept-Language": "zh-CN"})
+	require.NoError(t, err)
+	assert.Equal(t, "zh-CN", out.([]string)[1])
+
+	// URL 路径前缀指定的语言同样优先于应用默认语言
+	ctx := authtest.ServerContext(context.Background(), "/zh/orders", nil)
+	ctx = app_id.WithAppID(i18n.WithLanguage(ctx, "zh-CN"), "app-1")
+	out, err = handler(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "zh-CN", out.([]string)[1])
+
+	for _, appID := range []string{"app-2", "app-3"} {
+		_, err = call(appID, nil)
+		require.Error(t, err)
+		assert.Equal(t, int32(pkgErrors.ErrCodeForbidden), kratosErrors.FromError(err).Code)
+		assert.Equal(t, "应用不存在或已停用", kratosErrors.FromError(err).Message)
+	}
+
+	// 没有 appId 时放行（是否必填由调用方决定）
+	_, err = app_id.ValidateApp(registry, log.DefaultLogger)(passthroughHandler)(context.Background(), nil)
+	require.NoError(t, err)
+
+	// 注册表不可用
+	broken := &countingValidator{err: errors.New("timeout")}
+	_, err = app_id.ValidateApp(broken, log.DefaultLogger)(passthroughHandler)(app_id.WithAppID(context.Background(), "app-1"), nil)
+	require.Error(t, err)
+	assert.Equal(t, int32(pkgErrors.ErrCodeServiceUnavailable), kratosErrors.FromError(err).Code)
+
+	// 验证器返回 nil, nil 时视为应用不存在
+	_, err = app_id.ValidateApp(nilValidator{}, log.DefaultLogger)(passthroughHandler)(app_id.WithAppID(context.Background(), "app-1"), nil)
+	require.Error(t, err)
+	assert.Equal(t, int32(pkgErrors.ErrCodeForbidden), kratosErrors.FromError(err).Code)
+}
+
+// nilValidator 总是返回 nil, nil 的验证器
+type nilValidator struct{}
+
+func (nilValidator) GetApp(ctx context.Context, appID string) (*app_id.AppInfo, error) {
+	return nil, nil
+}
+
+// passthroughHandler 不检查 context 的下游 handler
+func passthroughHandler(ctx context.Context, req interface{}) (interface{}, error) {
+	return nil, nil
+}
@@ -0,0 +1,114 @@
+// Package app_id 提供 appId 中间件和工具函数
+package app_id
+
+import (
+	"context"
+	"errors"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/i18n"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+)
+
+// appInfoKey 是 context 中存储应用信息的键
+type appInfoKey struct{}
+
+// AppInfoKey 导出应用信息键，供外部使用
+var AppInfoKey = appInfoKey{}
+
+// WithAppInfo 将应用信息存入 context
+func WithAppInfo(ctx context.Context, app *AppInfo) context.Context {
+	return context.WithValue(ctx, AppInfoKey, app)
+}
+
+// GetAppInfoFromContext 从 context 获取应用信息（由 ValidateApp 中间件设置）
+func GetAppInfoFromContext(ctx context.Context) (*AppInfo, bool) {
+	app, ok := ctx.Value(AppInfoKey).(*AppInfo)
+	return app, ok && app != nil
+}
+
+// ValidateOption ValidateApp 中间件选项
+type ValidateOption func(*validateOptions)
+
+// validateOptions ValidateApp 中间件选项
+type validateOptions struct {
+	manager *pkgErrors.ErrorManager
+}
+
+// WithErrorManager 使用 ErrorManager 生成本地化的错误（默认使用内置中英文文案）
+func WithErrorManager(manager *pkgErrors.ErrorManager) ValidateOption {
+	return func(o *validateOptions) {
+		o.manager = manager
+	}
+}
+
+// ValidateApp 应用校验中间件，需要放在 Middleware（以及 i18n.Middleware）之后
+// context 中有 appId 时查询应用注册表：
+// - 应用不存在或已停用时返回本地化的 ErrCodeForbidden
+// - 注册表不可用时返回 ErrCodeServiceUnavailable
+// - 校验通过后将应用信息存入 context，可通过 GetAppInfoFromContext 获取；
+// 请求未指定语言（URL 路径前缀或 Accept-Language）时使用应用的默认语言，未携带 X-Timezone 时使用应用的时区
+// context 中没有 appId 时直接放行
+func ValidateApp(validator AppValidator, logger log.Logger, opts ...ValidateOption) middleware.Middleware {
+	logHelper := log.NewHelper(logger)
+	o := &validateOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			appID := GetAppIDFromContext(ctx)
+			if appID == "" {
+				return handler(ctx, req)
+			}
+
+			app, err := validator.GetApp(ctx, appID)
+			switch {
+			case errors.Is(err, ErrAppNotFound), err == nil && app == nil:
+				logHelper.WithContext(ctx).Warnf("app_id middleware: unknown appId=%s", appID)
+				return nil, newAppError(ctx, o.manager, pkgErrors.ErrCodeForbidden)
+			case err != nil:
+				logHelper.WithContext(ctx).Errorf("app_id middleware: get app %s failed: %v", appID, err)
+				return nil, newAppError(ctx, o.manager, pkgErrors.ErrCodeServiceUnavailable)
+			case app.Disabled:
+				logHelper.WithContext(ctx).Warnf("app_id middleware: disabled appId=%s", appID)
+				return nil, newAppError(ctx, o.manager, pkgErrors.ErrCodeForbidden)
+			}
+
+			ctx = WithAppInfo(ctx, app)
+			ctx = applyAppDefaults(ctx, app)
+			return handler(ctx, req)
+		}
+	}
+}
+
+// applyAppDefaults 请求未指定语言或时区时，使用应用的默认设置
+func applyAppDefaults(ctx context.Context, app *AppInfo) context.Context {
+	if app.DefaultLanguage != "" && !i18n.HasRequestedLanguage(ctx) {
+		ctx = i18n.WithLanguage(ctx, app.DefaultLanguage)
+	}
+	if tz, _ := ctx.Value(i18n.TimezoneKey).(string); tz == "" && app.Timezone != "" {
+		ctx = i18n.WithTimezone(ctx, app.Timezone)
+	}
+	return ctx
+}
+
+// appMessages 未配置 ErrorManager 时使用的内置文案
+var appMessages = pkgErrors.Messages{
+	"zh-CN": {
+		pkgErrors.ErrCodeForbidden:          "应用不存在或已停用",
+		pkgErrors.ErrCodeServiceUnavailable: "服务暂时不可用，请稍后重试",
+	},
+	"en-US": {
+		pkgErrors.ErrCodeForbidden:          "The application does not exist or has been disabled",
+		pkgErrors.ErrCodeServiceUnavailable: "Service temporarily unavailable, please try again later",
+	},
+}
+
+// newAppError 创建本地化的业务错误
+func newAppError(ctx context.Context, manager *pkgErrors.ErrorManager, code int32) *kratosErrors.Error {
+	return pkgErrors.NewBizErrorWithFallback(ctx, manager, appMessages, i18n.Language(ctx), code)
+}
@@ -0,0 +1,322 @@
+// Package auth 提供认证中间件和工具函数
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/internal/lru"
+	"github.com/gaoyong06/go-pkg/middleware/app_id"
+	"github.com/gaoyong06/go-pkg/middleware/developer_id"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrAPIKeyNotFound API Key 不存在
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrAPIKeyDisabled API Key 已禁用
+	ErrAPIKeyDisabled = errors.New("api key disabled")
+	// ErrAPIKeyExpired API Key 已过期
+	ErrAPIKeyExpired = errors.New("api key expired")
+)
+
+// APIKeyInfo API Key 对应的开发者和应用信息
+type APIKeyInfo struct {
+	DeveloperID string    `json:"developer_id" yaml:"developer_id"`
+	AppID       string    `json:"app_id" yaml:"app_id"`
+	Scopes      []string  `json:"scopes" yaml:"scopes"`         // 授权范围，如 "order:read"
+	ExpiresAt   time.Time `json:"expires_at" yaml:"expires_at"` // 过期时间，零值表示不过期
+	Disabled    bool      `json:"disabled" yaml:"disabled"`
+}
+
+// APIKeyStore API Key 存储接口
+// 生产环境通常由 api-key-service 客户端实现；key 不存在时返回 ErrAPIKeyNotFound
+type APIKeyStore interface {
+	LookupAPIKey(ctx context.Context, key string) (*APIKeyInfo, error)
+}
+
+// MemoryAPIKeyStore 内存 API Key 存储，适用于测试和本地开发
+// 内部只保存 key 的 SHA-256 摘要
+type MemoryAPIKeyStore struct {
+	mutex sync.RWMutex
+	keys  map[string]*APIKeyInfo
+}
+
+// NewMemoryAPIKeyStore 创建内存 API Key 存储
+// keys: API Key -> 开发者和应用信息
+func NewMemoryAPIKeyStore(keys map[string]*APIKeyInfo) *MemoryAPIKeyStore {
+	s := &MemoryAPIKeyStore{keys: make(map[string]*APIKeyInfo, len(keys))}
+	for key, info := range keys {
+		s.Add(key, info)
+	}
+	return s
+}
+
+// LoadAPIKeyFile 从 YAML/JSON 文件加载内存 API Key 存储
+// 文件格式：
+//
+//	keys:
+//	  ak_test_123:
+//	    developer_id: "1001"
+//	    app_id: "app-1"
+//	    scopes: [order:read]
+func LoadAPIKeyFile(path string) (*MemoryAPIKeyStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read api key file: %w", err)
+	}
+
+	var file struct {
+		Keys map[string]*APIKeyInfo `json:"keys" yaml:"keys"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse api key file %s: %w", path, err)
+	}
+	return NewMemoryAPIKeyStore(file.Keys), nil
+}
+
+// Add 添加或替换 API Key
+func (s *MemoryAPIKeyStore) Add(key string, info *APIKeyInfo) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.keys[tokenHash(key)] = info
+}
+
+// Remove 删除 API Key
+func (s *MemoryAPIKeyStore) Remove(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.keys, tokenHash(key))
+}
+
+// LookupAPIKey 查找 API Key
+func (s *MemoryAPIKeyStore) LookupAPIKey(ctx context.Context, key string) (*APIKeyInfo, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	info, ok := s.keys[tokenHash(key)]
+	if !ok || info == nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	c := *info
+	return &c, nil
+}
+
+// APIKeyConfig API Key 认证配置
+type APIKeyConfig struct {
+	// 请求头名称，默认 "X-Api-Key"
+	Header string `json:"header" yaml:"header"`
+
+	// Query 参数名称（仅 HTTP），如 "api_key"；默认为空，不从 Query 读取
+	// Query 参数会出现在访问日志和代理日志中，只在客户端无法设置请求头时开启
+	QueryParam string `json:"query_param" yaml:"query_param"`
+
+	// 本地缓存最大条目数，默认 1000
+	CacheSize int `json:"cache_size" yaml:"cache_size"`
+
+	// 验证结果缓存时间，默认 1 分钟；不存在的 key 也会缓存，避免被反复查询
+	CacheTTL time.Duration `json:"cache_ttl" yaml:"cache_ttl"`
+}
+
+const (
+	defaultAPIKeyHeader    = "X-Api-Key"
+	defaultAPIKeyCacheSize = 1000
+	defaultAPIKeyCacheTTL  = time.Minute
+)
+
+// apiKeyResult 缓存的验证结果
+type apiKeyResult struct {
+	info *APIKeyInfo
+	err  error
+}
+
+// APIKeyAuthenticator API Key 认证器，带本地缓存
+type APIKeyAuthenticator struct {
+	store  APIKeyStore
+	config APIKeyConfig
+	cache  *lru.Cache[*apiKeyResult]
+}
+
+// NewAPIKeyAuthenticator 创建 API Key 认证器
+// config 为 nil 时使用默认配置
+func NewAPIKeyAuthenticator(store APIKeyStore, config *APIKeyConfig) *APIKeyAuthenticator {
+	conf := APIKeyConfig{}
+	if config != nil {
+		conf = *config
+	}
+	if conf.Header == "" {
+		conf.Header = defaultAPIKeyHeader
+	}
+	if conf.CacheSize <= 0 {
+		conf.CacheSize = defaultAPIKeyCacheSize
+	}
+	if conf.CacheTTL <= 0 {
+		conf.CacheTTL = defaultAPIKeyCacheTTL
+	}
+
+	return &APIKeyAuthenticator{
+		store:  store,
+		config: conf,
+		cache:  lru.New[*apiKeyResult](conf.CacheSize),
+	}
+}
+
+// Authenticate 验证 API Key，返回开发者和应用信息
+// 已禁用或已过期的 key 分别返回 ErrAPIKeyDisabled、ErrAPIKeyExpired
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, key string) (*APIKeyInfo, error) {
+	if key == "" {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	now := timeNow()
+	cacheKey := tokenHash(key)
+	result, ok := a.cache.Get(cacheKey, now)
+	if !ok {
+		info, err := a.store.LookupAPIKey(ctx, key)
+		if err != nil && !errors.Is(err, ErrAPIKeyNotFound) {
+			// 存储故障不缓存，下次请求重试
+			return nil, fmt.Errorf("lookup api key failed: %w", err)
+		}
+		result = &apiKeyResult{info: info, err: err}
+		a.cache.Set(cacheKey, result, now.Add(a.config.CacheTTL))
+	}
+
+	if result.err != nil {
+		return nil, result.err
+	}
+	if result.info.Disabled {
+		return nil, ErrAPIKeyDisabled
+	}
+	if !result.info.ExpiresAt.IsZero() && now.After(result.info.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	info := *result.info
+	return &info, nil
+}
+
+// Invalidate 清除 API Key 的缓存（如 key 被禁用或删除后）
+func (a *APIKeyAuthenticator) Invalidate(key string) {
+	a.cache.Delete(tokenHash(key))
+}
+
+// extractKey 从请求头或 Query 参数中提取 API Key
+func (a *APIKeyAuthenticator) extractKey(tr transport.Transporter) string {
+	if key := strings.TrimSpace(tr.RequestHeader().Get(a.config.Header)); key != "" {
+		return key
+	}
+
+	if a.config.QueryParam == "" {
+		return ""
+	}
+	if httpTr, ok := tr.(*kratoshttp.Transport); ok {
+		if req := httpTr.Request(); req != nil && req.URL != nil {
+			return strings.TrimSpace(req.URL.Query().Get(a.config.QueryParam))
+		}
+	}
+	return ""
+}
+
+// APIKeyMiddleware API Key 认证中间件
+// 从 X-Api-Key 请求头（或配置的 Query 参数）读取 API Key，验证通过后
+// 将开发者 ID 和应用 ID 分别存入 developer_id 和 app_id 的 context，API Key 信息存入 APIKeyInfoKey
+// API Key 代表开发者而不是终端用户，不写入 UserClaims，RequireAuth、RequirePolicy 等面向用户的检查不会放行
+// 与 Middleware 一致，验证失败不阻止请求，需要强制认证时配合 RequireAPIKey 使用
+func APIKeyMiddleware(authenticator *APIKeyAuthenticator, config *Config, logger log.Logger) middleware.Middleware {
+	logHelper := log.NewHelper(logger)
+	skip := config.newSkipper()
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			if skip.skip(tr) {
+				return handler(ctx, req)
+			}
+
+			key := authenticator.extractKey(tr)
+			if key == "" {
+				return handler(ctx, req)
+			}
+
+			info, err := authenticator.Authenticate(ctx, key)
+			if err != nil {
+				logHelper.Warnf("API key validation failed: %v", err)
+				return handler(ctx, req)
+			}
+
+			ctx = developer_id.WithDeveloperID(ctx, info.DeveloperID)
+			ctx = app_id.WithAppID(ctx, info.AppID)
+			ctx = WithAPIKeyInfo(ctx, info)
+
+			return handler(ctx, req)
+		}
+	}
+}
+
+// apiKeyInfoKey 是 context 中存储 API Key 信息的键
+type apiKeyInfoKey struct{}
+
+// APIKeyInfoKey 导出 API Key 信息键，供外部使用
+var APIKeyInfoKey = apiKeyInfoKey{}
+
+// WithAPIKeyInfo 将 API Key 信息存入 context
+func WithAPIKeyInfo(ctx context.Context, info *APIKeyInfo) context.Context {
+	return context.WithValue(ctx, APIKeyInfoKey, info)
+}
+
+// GetAPIKeyInfoFromContext 从 context 中获取 API Key 信息（开发者 ID、应用 ID、授权范围）
+func GetAPIKeyInfoFromContext(ctx context.Context) (*APIKeyInfo, bool) {
+	info, ok := ctx.Value(APIKeyInfoKey).(*APIKeyInfo)
+	return info, ok && info != nil
+}
+
+// HasScope 是否拥有指定授权范围
+func (i *APIKeyInfo) HasScope(scope string) bool {
+	if i == nil || scope == "" {
+		return false
+	}
+	for _, s := range i.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireAPIKey 要求 API Key 认证的中间件，context 中没有 API Key 信息时返回 ErrCodeUnauthorized
+// 需要放在 APIKeyMiddleware 之后；只检查 APIKeyMiddleware 写入的 API Key 信息，
+// 不使用 developer_id（该值可能来自客户端传入的 X-Developer-Id 请求头）
+func RequireAPIKey(config *Config, opts ...MiddlewareOption) middleware.Middleware {
+	options := newMiddlewareOptions(opts)
+	skip := config.newSkipper()
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				if skip.skip(tr) {
+					return handler(ctx, req)
+				}
+			}
+
+			if _, ok := GetAPIKeyInfoFromContext(ctx); !ok {
+				options.audit(ctx, FailureMissing, nil)
+				return nil, newAuthError(ctx, options.errorManager, pkgErrors.ErrCodeUnauthorized)
+			}
+
+			return handler(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/app_id"
+	"github.com/gaoyong06/go-pkg/middleware/developer_id"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingStore struct {
+	APIKeyStore
+	calls int
+}
+
+func (s *countingStore) LookupAPIKey(ctx context.Context, key string) (*APIKeyInfo, error) {
+	s.calls++
+	return s.APIKeyStore.LookupAPIKey(ctx, key)
+}
+
+func TestLoadAPIKeyFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+keys:
+  ak_test_123:
+    developer_id: "1001"
+    app_id: app-1
+    scopes: [order:read]
+  ak_disabled:
+    developer_id: "1002"
+    disabled: true
+`), 0o600))
+
+	store, err := LoadAPIKeyFile(file)
+	require.NoError(t, err)
+	authenticator := NewAPIKeyAuthenticator(store, nil)
+
+	info, err := authenticator.Authenticate(context.Background(), "ak_test_123")
+	require.NoError(t, err)
+	assert.Equal(t, &APIKeyInfo{DeveloperID: "1001", AppID: "app-1", Scopes: []string{"order:read"}}, info)
+
+	_, err = authenticator.Authenticate(context.Background(), "ak_disabled")
+	assert.ErrorIs(t, err, ErrAPIKeyDisabled)
+
+	_, err = authenticator.Authenticate(context.Background(), "ak_unknown")
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+}
+
+func TestAPIKeyAuthenticator_Cache(t *testing.T) {
+	store := &countingStore{APIKeyStore: NewMemoryAPIKeyStore(map[string]*APIKeyInfo{
+		"ak_1": {DeveloperID: "1001", AppID: "app-1", ExpiresAt: time.Now().Add(time.Hour)},
+	})}
+	authenticator := NewAPIKeyAuthenticator(store, &APIKeyConfig{CacheTTL: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		_, err := authenticator.Authenticate(context.Background(), "ak_1")
+		require.NoError(t, err)
+		_, err = authenticator.Authenticate(context.Background(), "ak_unknown")
+		require.True(t, errors.Is(err, ErrAPIKeyNotFound))
+	}
+	assert.Equal(t, 2, store.calls)
+
+	authenticator.Invalidate("ak_1")
+	_, err := authenticator.Authenticate(context.Background(), "ak_1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, store.calls)
+
+	// 缓存期间 key 过期也会被拒绝
+	timeNow = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	defer func() { timeNow = time.Now }()
+	_, err = authenticator.Authenticate(context.Background(), "ak_1")
+	assert.ErrorIs(t, err, ErrAPIKeyExpired)
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	authenticator := NewAPIKeyAuthenticator(NewMemoryAPIKeyStore(map[string]*APIKeyInfo{
+		"ak_1": {DeveloperID: "1001", AppID: "app-1", Scopes: []string{"order:read"}},
+	}), nil)
+
+	var gotCtx context.Context
+	handler := APIKeyMiddleware(authenticator, nil, log.DefaultLogger)(RequireAPIKey(nil)(
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			gotCtx = ctx
+			return "ok", nil
+		}))
+
+	ctx := transport.NewServerContext(context.Background(), &testTransport{header: headerCarrier{"X-Api-Key": "ak_1"}})
+	_, err := handler(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "1001", developer_id.GetDeveloperIDFromContext(gotCtx))
+	assert.Equal(t, "app-1", app_id.GetAppIDFromContext(gotCtx))
+	info, ok := GetAPIKeyInfoFromContext(gotCtx)
+	require.True(t, ok)
+	assert.True(t, info.HasScope("order:read"))
+
+	// API Key 不代表终端用户
+	_, ok = GetUserClaimsFromContext(gotCtx)
+	assert.False(t, ok)
+	assert.Empty(t, GetUserIDFromContext(gotCtx))
+
+	ctx = transport.NewServerContext(context.Background(), &testTransport{header: headerCarrier{"X-Api-Key": "ak_bad"}})
+	_, err = handler(ctx, nil)
+	assert.Error(t, err)
+}
+
+func TestRequireAPIKeyIgnoresDeveloperIDHeader(t *testing.T) {
+	authenticator := NewAPIKeyAuthenticator(NewMemoryAPIKeyStore(map[string]*APIKeyInfo{
+		"ak_app": {AppID: "app-1"},
+	}), nil)
+	chain := middleware.Chain(
+		developer_id.Middleware(),
+		APIKeyMiddleware(authenticator, nil, log.DefaultLogger),
+		RequireAPIKey(nil),
+	)
+	handler := chain(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+
+	// 客户端伪造的 X-Developer-Id 不能代替 API Key
+	_, err := handler(transport.NewServerContext(context.Background(), &testTransport{header: headerCarrier{"X-Developer-Id": "1001"}}), nil)
+	require.Error(t, err)
+	assert.Equal(t, int32(pkgErrors.ErrCodeUnauthorized), kratosErrors.FromError(err).Code)
+
+	// 有效的 API Key 即使没有关联开发者也能通过
+	_, err = handler(transport.NewServerContext(context.Background(), &testTransport{header: headerCarrier{"X-Api-Key": "ak_app"}}), nil)
+	assert.NoError(t, err)
+}
+
+func TestAPIKeyQueryParamOptIn(t *testing.T) {
+	store := NewMemoryAPIKeyStore(map[string]*APIKeyInfo{"ak_1": {DeveloperID: "1001"}})
+
+	developerID := func(config *APIKeyConfig) string {
+		srv := kratoshttp.NewServer(kratoshttp.Middleware(APIKeyMiddleware(NewAPIKeyAuthenticator(store, config), nil, log.DefaultLogger)))
+		srv.Route("/").GET("/orders", func(ctx kratoshttp.Context) error {
+			h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+				return developer_id.GetDeveloperIDFromContext(ctx), nil
+			})
+			out, _ := h(ctx, nil)
+			return ctx.String(http.StatusOK, out.(string))
+		})
+		server := httptest.NewServer(srv)
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/orders?api_key=ak_1")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	// 默认不从 Query 参数读取，避免 key 出现在访问日志中
+	assert.Empty(t, developerID(nil))
+	assert.Equal(t, "1001", developerID(&APIKeyConfig{QueryParam: "api_key"}))
+}
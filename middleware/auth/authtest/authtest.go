@@ -0,0 +1,172 @@
+// Package authtest 提供 auth 中间件的测试工具
+// 包括可编程的假验证器、构造 Kratos 服务端/客户端 context 的辅助函数，
+// 以及基于 bufconn 的进程内 passport-service 替身
+package authtest
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/gaoyong06/go-pkg/middleware/auth"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// ErrUnknownToken FakeValidator 遇到未注册的 token 时返回的错误
+var ErrUnknownToken = errors.New("authtest: unknown token")
+
+// FakeValidator 可编程的 TokenValidator，按 token 返回预设的声明或错误，并记录调用
+type FakeValidator struct {
+	mutex  sync.Mutex
+	claims map[string]*auth.UserClaims
+	errs   map[string]error
+	calls  []string
+}
+
+// NewFakeValidator 创建假验证器
+func NewFakeValidator() *FakeValidator {
+	return &FakeValidator{
+		claims: make(map[string]*auth.UserClaims),
+		errs:   make(map[string]error),
+	}
+}
+
+// AddToken 注册有效 token
+func (v *FakeValidator) AddToken(token string, claims *auth.UserClaims) *FakeValidator {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.claims[token] = claims
+	delete(v.errs, token)
+	return v
+}
+
+// AddError 注册验证失败的 token，如 jwt.ErrTokenExpired、auth.ErrTokenRevoked
+func (v *FakeValidator) AddError(token string, err error) *FakeValidator {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.errs[token] = err
+	delete(v.claims, token)
+	return v
+}
+
+// ValidateToken 实现 auth.TokenValidator
+func (v *FakeValidator) ValidateToken(ctx context.Context, token string) (*auth.UserClaims, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.calls = append(v.calls, token)
+	if err, ok := v.errs[token]; ok {
+		return nil, err
+	}
+	if claims, ok := v.claims[token]; ok {
+		c := *claims
+		return &c, nil
+	}
+	return nil, ErrUnknownToken
+}
+
+// Calls 返回按顺序记录的已验证 token
+func (v *FakeValidator) Calls() []string {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return append([]string(nil), v.calls...)
+}
+
+// Header 基于 map 的 transport.Header 实现（key 区分大小写）
+type Header map[string][]string
+
+// Get 获取第一个值
+func (h Header) Get(key string) string {
+	if values := h[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Set 设置值
+func (h Header) Set(key, value string) {
+	h[key] = []string{value}
+}
+
+// Add 追加值
+func (h Header) Add(key, value string) {
+	h[key] = append(h[key], value)
+}
+
+// Keys 返回全部 key
+func (h Header) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Values 返回全部值
+func (h Header) Values(key string) []string {
+	return h[key]
+}
+
+// Transport 测试用的 transport.Transporter 实现
+type Transport struct {
+	kind        transport.Kind
+	endpoint    string
+	operation   string
+	reqHeader   Header
+	replyHeader Header
+}
+
+// NewTransport 创建测试用 Transport
+// headers: 请求头，如 {"Authorization": "Bearer token"}
+func NewTransport(kind transport.Kind, operation string, headers map[string]string) *Transport {
+	reqHeader := Header{}
+	for k, v := range headers {
+		reqHeader.Set(k, v)
+	}
+	return &Transport{
+		kind:        kind,
+		operation:   operation,
+		reqHeader:   reqHeader,
+		replyHeader: Header{},
+	}
+}
+
+// Kind 传输类型
+func (tr *Transport) Kind() transport.Kind { return tr.kind }
+
+// Endpoint 服务地址
+func (tr *Transport) Endpoint() string { return tr.endpoint }
+
+// Operation Kratos operation
+func (tr *Transport) Operation() string { return tr.operation }
+
+// RequestHeader 请求头
+func (tr *Transport) RequestHeader() transport.Header { return tr.reqHeader }
+
+// ReplyHeader 响应头
+func (tr *Transport) ReplyHeader() transport.Header { return tr.replyHeader }
+
+// ServerContext 构造带 Kratos 服务端 transport 的 context（gRPC 类型）
+func ServerContext(ctx context.Context, operation string, headers map[string]string) context.Context {
+	return transport.NewServerContext(ctx, NewTransport(transport.KindGRPC, operation, headers))
+}
+
+// ClientContext 构造带 Kratos 客户端 transport 的 context，返回的 Transport 可用于检查写入的请求头
+func ClientContext(ctx context.Context, operation string) (context.Context, *Transport) {
+	tr := NewTransport(transport.KindGRPC, operation, nil)
+	return transport.NewClientContext(ctx, tr), tr
+}
+
+// BearerContext 构造携带 Bearer token 的服务端 context
+func BearerContext(ctx context.Context, operation, token string) context.Context {
+	return ServerContext(ctx, operation, map[string]string{"Authorization": "Bearer " + token})
+}
+
+// WithClaims 构造已认证用户的 context（跳过认证中间件，直接测试业务代码）
+func WithClaims(ctx context.Context, userID string, roles ...string) context.Context {
+	claims := &auth.UserClaims{UserID: userID, Roles: roles}
+	if len(roles) > 0 {
+		claims.Role = roles[0]
+	}
+	return auth.WithUserClaims(ctx, claims)
+}
@@ -0,0 +1,124 @@
+// Package authtest 提供 auth 中间件的测试工具
+package authtest
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/gaoyong06/go-pkg/middleware/auth"
+	"github.com/gaoyong06/go-pkg/middleware/auth/passportpb"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// bufSize bufconn 缓冲区大小
+const bufSize = 1 << 20
+
+// PassportStub 进程内的 passport-service 替身，基于 bufconn，不占用网络端口
+type PassportStub struct {
+	mutex       sync.Mutex
+	tokens      map[string]*auth.UserClaims
+	expired     map[string]bool
+	unavailable bool
+
+	server *grpc.Server
+	conn   *grpc.ClientConn
+}
+
+// NewPassportStub 启动 passport-service 替身，测试结束时自动关闭
+// tokens: 有效 token -> 用户声明（只使用 UserID 和 Role，与 passport-service 的响应一致）
+func NewPassportStub(t testing.TB, tokens map[string]*auth.UserClaims) *PassportStub {
+	t.Helper()
+
+	s := &PassportStub{tokens: make(map[string]*auth.UserClaims), expired: make(map[string]bool)}
+	for token, claims := range tokens {
+		s.tokens[token] = claims
+	}
+
+	listener := bufconn.Listen(bufSize)
+	s.server = grpc.NewServer()
+	passportpb.RegisterPassportServer(s.server, s)
+	go func() {
+		_ = s.server.Serve(listener)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("authtest: dial passport stub: %v", err)
+	}
+	s.conn = conn
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		s.server.Stop()
+	})
+	return s
+}
+
+// Conn 连接到替身的 gRPC 连接
+func (s *PassportStub) Conn() *grpc.ClientConn {
+	return s.conn
+}
+
+// Validator 创建连接到替身的 PassportTokenValidator
+func (s *PassportStub) Validator(logger log.Logger) *auth.PassportTokenValidator {
+	return auth.NewPassportTokenValidatorWithConn(s.conn, logger)
+}
+
+// AddToken 注册有效 token
+func (s *PassportStub) AddToken(token string, claims *auth.UserClaims) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tokens[token] = claims
+}
+
+// RemoveToken 删除 token（模拟登出或吊销）
+func (s *PassportStub) RemoveToken(token string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.tokens, token)
+}
+
+// ExpireToken 将 token 标记为已过期，ValidateToken 返回原因为 auth.ReasonTokenExpired 的错误
+func (s *PassportStub) ExpireToken(token string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.expired[token] = true
+}
+
+// SetUnavailable 模拟 passport-service 不可用，ValidateToken 返回 codes.Unavailable
+func (s *PassportStub) SetUnavailable(unavailable bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.unavailable = unavailable
+}
+
+// ValidateToken 实现 passportpb.PassportServer
+func (s *PassportStub) ValidateToken(ctx context.Context, req *passportpb.ValidateTokenRequest) (*passportpb.ValidateTokenResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.unavailable {
+		return nil, status.Error(codes.Unavailable, "passport stub unavailable")
+	}
+	if s.expired[req.GetToken()] {
+		return nil, kratosErrors.Unauthorized(auth.ReasonTokenExpired, "token expired")
+	}
+	claims, ok := s.tokens[req.GetToken()]
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return &passportpb.ValidateTokenResponse{UserId: claims.UserID, Role: claims.Role}, nil
+}
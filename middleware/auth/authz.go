@@ -0,0 +1,279 @@
+// Package auth 提供认证中间件和工具函数
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/route"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy 单个接口的授权策略
+// 非 Public 的策略至少要求已认证；AnyRoles、AllRoles、Scopes 同时配置时需要全部满足
+type Policy struct {
+	// 允许匿名访问
+	Public bool `json:"public" yaml:"public"`
+
+	// 拥有其中任意一个角色即可
+	AnyRoles []string `json:"any_roles" yaml:"any_roles"`
+
+	// 需要拥有全部角色
+	AllRoles []string `json:"all_roles" yaml:"all_roles"`
+
+	// 需要拥有全部授权范围，如 ["order:read"]
+	Scopes []string `json:"scopes" yaml:"scopes"`
+}
+
+// PolicyConfig 授权策略配置
+//
+// YAML 示例：
+//
+//	role_hierarchy:
+//	  admin: [editor]
+//	  editor: [viewer]
+//	default:
+//	  any_roles: [viewer]
+//	policies:
+//	  /api.order.v1.Order/GetOrder:
+//	    scopes: [order:read]
+//	  /api.order.v1.Order/*:
+//	    any_roles: [editor]
+//	  /api.order.v1.Order/ListPublic:
+//	    public: true
+//	  GET /v1/reports/**:
+//	    any_roles: [viewer]
+type PolicyConfig struct {
+	// 角色继承关系：角色 -> 隐含的角色（可传递，如 admin 隐含 editor，editor 隐含 viewer）
+	RoleHierarchy map[string][]string `json:"role_hierarchy" yaml:"role_hierarchy"`
+
+	// 按 Kratos operation 配置的策略，key 支持 route 包的模式语法；精确匹配优先，其次最长的模式
+	// 限定 HTTP 方法的 key（如 "GET /v1/orders"）只匹配对应方法的 HTTP 请求，且优先于不限方法的同一 operation
+	Policies map[string]*Policy `json:"policies" yaml:"policies"`
+
+	// 未匹配到任何策略时使用的默认策略，为空时放行
+	Default *Policy `json:"default" yaml:"default"`
+}
+
+// LoadPolicyConfig 从 YAML 文件加载授权策略配置
+func LoadPolicyConfig(path string) (*PolicyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy file: %w", err)
+	}
+
+	var config PolicyConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parse policy file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// AuthorizerOption 授权器选项
+type AuthorizerOption func(*Authorizer)
+
+// WithErrorManager 使用 ErrorManager 生成本地化的拒绝错误（文案来自服务的 errors.json）
+func WithErrorManager(manager *pkgErrors.ErrorManager) AuthorizerOption {
+	return func(a *Authorizer) {
+		a.errorManager = manager
+	}
+}
+
+// policyRule 编译后的策略匹配规则
+type policyRule struct {
+	rule   *route.Rule
+	policy *Policy
+}
+
+// Authorizer 基于角色和授权范围的授权器（RBAC）
+type Authorizer struct {
+	config       PolicyConfig
+	rules        []policyRule        // 按优先级排序：精确匹配（限定方法的在前），其次按模式长度降序
+	implied      map[string][]string // 角色 -> 展开后的全部隐含角色（含自身）
+	errorManager *pkgErrors.ErrorManager
+}
+
+// NewAuthorizer 创建授权器
+// 无效的模式不匹配任何请求
+func NewAuthorizer(config *PolicyConfig, opts ...AuthorizerOption) *Authorizer {
+	a := &Authorizer{implied: make(map[string][]string)}
+	if config != nil {
+		a.config = *config
+	}
+
+	for pattern, policy := range a.config.Policies {
+		if rule, err := route.Compile(pattern); err == nil {
+			a.rules = append(a.rules, policyRule{rule: rule, policy: policy})
+		}
+	}
+	sort.Slice(a.rules, func(i, j int) bool {
+		pi, pj := a.rules[i].rule.Pattern(), a.rules[j].rule.Pattern()
+		if li, lj := route.IsLiteral(pi), route.IsLiteral(pj); li != lj {
+			return li
+		}
+		if len(pi) != len(pj) {
+			return len(pi) > len(pj)
+		}
+		return pi < pj
+	})
+
+	for role := range a.config.RoleHierarchy {
+		a.implied[role] = expandRole(role, a.config.RoleHierarchy)
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// PolicyFor 获取接口对应的策略，未配置时返回默认策略（可能为 nil）
+// 不区分 HTTP 方法，限定了方法的策略不会匹配；HTTP 请求使用 PolicyForRequest
+func (a *Authorizer) PolicyFor(operation string) *Policy {
+	return a.PolicyForRequest("", operation)
+}
+
+// PolicyForRequest 按 HTTP 方法和 Kratos operation 获取策略，未配置时返回默认策略（可能为 nil）
+// method 为空（如 gRPC 调用）时，限定了 HTTP 方法的策略不匹配
+func (a *Authorizer) PolicyForRequest(method, operation string) *Policy {
+	for _, r := range a.rules {
+		if r.rule.Match(method, operation) {
+			return r.policy
+		}
+	}
+	return a.config.Default
+}
+
+// Roles 返回用户的全部有效角色（按角色继承关系展开）
+func (a *Authorizer) Roles(claims *UserClaims) map[string]bool {
+	roles := make(map[string]bool)
+	for _, role := range claims.AllRoles() {
+		roles[role] = true
+		for _, implied := range a.implied[role] {
+			roles[implied] = true
+		}
+	}
+	return roles
+}
+
+// Allowed 判断用户是否满足策略，policy 为 nil 时放行
+func (a *Authorizer) Allowed(claims *UserClaims, policy *Policy) bool {
+	if policy == nil || policy.Public {
+		return true
+	}
+	if claims == nil || claims.UserID == "" {
+		return false
+	}
+
+	roles := a.Roles(claims)
+	if len(policy.AnyRoles) > 0 {
+		matched := false
+		for _, role := range policy.AnyRoles {
+			if roles[role] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, role := range policy.AllRoles {
+		if !roles[role] {
+			return false
+		}
+	}
+	for _, scope := range policy.Scopes {
+		if !claims.HasScope(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// Authorize 按接口策略校验 context 中的用户
+// context 中有 HTTP 请求时按请求的 HTTP 方法匹配限定了方法的策略
+// 未认证返回 ErrCodeUnauthorized，权限不足返回 ErrCodeForbidden（均为本地化的业务错误）
+func (a *Authorizer) Authorize(ctx context.Context, operation string) error {
+	var method string
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		method, _, _ = httpRequestPath(tr)
+	}
+	policy := a.PolicyForRequest(method, operation)
+	if policy == nil || policy.Public {
+		return nil
+	}
+
+	claims, _ := GetUserClaimsFromContext(ctx)
+	if claims == nil || claims.UserID == "" {
+		return a.newError(ctx, pkgErrors.ErrCodeUnauthorized)
+	}
+	if !a.Allowed(claims, policy) {
+		return a.newError(ctx, pkgErrors.ErrCodeForbidden)
+	}
+	return nil
+}
+
+// newError 创建本地化的拒绝错误
+func (a *Authorizer) newError(ctx context.Context, code int32) *kratosErrors.Error {
+	return newAuthError(ctx, a.errorManager, code)
+}
+
+// RequirePolicy 声明式授权中间件，按 Kratos operation 匹配策略并校验用户角色和授权范围
+// 需要放在 Middleware（认证）之后；config 中的白名单路径跳过授权
+func RequirePolicy(authorizer *Authorizer, config *Config, logger log.Logger) middleware.Middleware {
+	logHelper := log.NewHelper(logger)
+	skip := config.newSkipper()
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+
+			if skip.skip(tr) {
+				return handler(ctx, req)
+			}
+
+			operation := tr.Operation()
+
+			if err := authorizer.Authorize(ctx, operation); err != nil {
+				logHelper.Warnf("Authorization denied: operation=%s user=%s", operation, GetUserIDFromContext(ctx))
+				return nil, err
+			}
+
+			return handler(ctx, req)
+		}
+	}
+}
+
+// expandRole 展开角色的全部隐含角色（含自身），忽略循环继承
+func expandRole(role string, hierarchy map[string][]string) []string {
+	seen := map[string]bool{role: true}
+	queue := []string{role}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, implied := range hierarchy[current] {
+			if !seen[implied] {
+				seen[implied] = true
+				queue = append(queue, implied)
+			}
+		}
+	}
+
+	roles := make([]string, 0, len(seen))
+	for r := range seen {
+		roles = append(roles, r)
+	}
+	sort.Strings(roles)
+	return roles
+}
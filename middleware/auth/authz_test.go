@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/i18n"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicyYAML = `
+role_hierarchy:
+  admin: [editor]
+  editor: [viewer]
+policies:
+  /api.order.v1.Order/*:
+    any_roles: [editor]
+  /api.order.v1.Order/GetOrder:
+    any_roles: [viewer]
+    scopes: [order:read]
+  /api.order.v1.Order/ListPublic:
+    public: true
+  /api.order.v1.Order/Refund:
+    all_roles: [editor, finance]
+`
+
+func loadTestAuthorizer(t *testing.T) *Authorizer {
+	file := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(testPolicyYAML), 0o600))
+	config, err := LoadPolicyConfig(file)
+	require.NoError(t, err)
+	return NewAuthorizer(config)
+}
+
+func TestAuthorizer_Authorize(t *testing.T) {
+	authorizer := loadTestAuthorizer(t)
+
+	admin := &UserClaims{UserID: "1", Role: "admin"}
+	viewer := &UserClaims{UserID: "2", Role: "viewer", Scopes: []string{"order:read"}}
+	finance := &UserClaims{UserID: "3", Roles: []string{"editor", "finance"}}
+
+	tests := []struct {
+		name      string
+		claims    *UserClaims
+		operation string
+		code      int32
+	}{
+		{"公开接口允许匿名", nil, "/api.order.v1.Order/ListPublic", 0},
+		{"未认证", nil, "/api.order.v1.Order/GetOrder", pkgErrors.ErrCodeUnauthorized},
+		{"精确策略优先于通配符", viewer, "/api.order.v1.Order/GetOrder", 0},
+		{"缺少授权范围", admin, "/api.order.v1.Order/GetOrder", pkgErrors.ErrCodeForbidden},
+		{"角色继承", admin, "/api.order.v1.Order/UpdateOrder", 0},
+		{"角色不足", viewer, "/api.order.v1.Order/UpdateOrder", pkgErrors.ErrCodeForbidden},
+		{"需要全部角色", finance, "/api.order.v1.Order/Refund", 0},
+		{"缺少部分角色", admin, "/api.order.v1.Order/Refund", pkgErrors.ErrCodeForbidden},
+		{"未配置策略放行", nil, "/api.user.v1.User/Get", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.claims != nil {
+				ctx = WithUserClaims(ctx, tt.claims)
+			}
+			err := authorizer.Authorize(ctx, tt.operation)
+			if tt.code == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.code, kratosErrors.FromError(err).Code)
+		})
+	}
+}
+
+func TestRequirePolicy_LocalizedDenial(t *testing.T) {
+	authorizer := loadTestAuthorizer(t)
+	handler := RequirePolicy(authorizer, &Config{SkipPaths: []string{"/api.order.v1.Order/Health"}}, log.DefaultLogger)(
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "ok", nil
+		})
+
+	call := func(operation, lang string) error {
+		ctx := transport.NewServerContext(context.Background(), &testTransport{header: headerCarrier{}, operation: operation})
+		ctx = i18n.WithLanguage(ctx, lang)
+		ctx = WithUserClaims(ctx, &UserClaims{UserID: "2", Role: "viewer"})
+		_, err := handler(ctx, nil)
+		return err
+	}
+
+	err := call("/api.order.v1.Order/UpdateOrder", "en-US")
+	require.Error(t, err)
+	assert.Equal(t, "You do not have permission to perform this operation", kratosErrors.FromError(err).Message)
+
+	err = call("/api.order.v1.Order/UpdateOrder", "zh-CN")
+	assert.Equal(t, "没有权限执行该操作", kratosErrors.FromError(err).Message)
+
+	assert.NoError(t, call("/api.order.v1.Order/Health", "zh-CN"))
+}
+
+func TestRequirePolicy_MethodQualified(t *testing.T) {
+	authorizer := NewAuthorizer(&PolicyConfig{Policies: map[string]*Policy{
+		"/v1/orders":      {AnyRoles: []string{"viewer"}},
+		"POST /v1/orders": {AnyRoles: []string{"editor"}},
+	}})
+
+	// gRPC 调用和不区分方法的查询不匹配限定了方法的策略
+	assert.Equal(t, []string{"viewer"}, authorizer.PolicyFor("/v1/orders").AnyRoles)
+	assert.Equal(t, []string{"editor"}, authorizer.PolicyForRequest(http.MethodPost, "/v1/orders").AnyRoles)
+
+	srv := kratoshttp.NewServer(kratoshttp.Middleware(RequirePolicy(authorizer, nil, log.DefaultLogger)))
+	handle := func(ctx kratoshttp.Context) error {
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "ok", nil
+		})
+		if _, err := h(WithUserClaims(ctx, &UserClaims{UserID: "2", Role: "viewer"}), nil); err != nil {
+			// 业务错误码不是合法的 HTTP 状态码，服务中由 response 包的错误编码器处理
+			return ctx.String(http.StatusForbidden, err.Error())
+		}
+		return ctx.String(http.StatusOK, "ok")
+	}
+	srv.Route("/").GET("/v1/orders", handle)
+	srv.Route("/").POST("/v1/orders", handle)
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	status := func(method string) int {
+		req, err := http.NewRequest(method, server.URL+"/v1/orders", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, status(http.MethodGet))
+	assert.Equal(t, http.StatusForbidden, status(http.MethodPost), "method-qualified policy takes precedence")
+}
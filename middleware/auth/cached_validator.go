@@ -0,0 +1,348 @@
+// Package auth 提供认证中间件和工具函数
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gaoyong06/go-pkg/internal/lru"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrTokenRevoked token 已被吊销（如用户已登出）
+var ErrTokenRevoked = errors.New("token revoked")
+
+// CacheConfig Token 验证缓存配置
+type CacheConfig struct {
+	// 本地 LRU 缓存最大条目数，默认 10000
+	Size int `json:"size" yaml:"size"`
+
+	// 缓存时间上限，默认 5 分钟；实际 TTL 不会超过 token 自身的过期时间
+	TTL time.Duration `json:"ttl" yaml:"ttl"`
+
+	// 本地吊销标记最大条目数，默认 100000；与验证缓存分开存放，缓存的淘汰不会影响吊销标记
+	// 超出容量后最久未使用的标记会被淘汰，未配置 Redis 时被淘汰的 token 会重新生效
+	RevokedSize int `json:"revoked_size" yaml:"revoked_size"`
+
+	// Redis 键前缀，默认 "auth:token:"
+	RedisKeyPrefix string `json:"redis_key_prefix" yaml:"redis_key_prefix"`
+
+	// 吊销通知的 Redis pub/sub 频道，默认 "auth:token:revoked"
+	RevocationChannel string `json:"revocation_channel" yaml:"revocation_channel"`
+}
+
+const (
+	defaultCacheSize         = 10000
+	defaultRevokedSize       = 100000
+	defaultCacheTTL          = 5 * time.Minute
+	defaultRedisKeyPrefix    = "auth:token:"
+	defaultRevocationChannel = "auth:token:revoked"
+)
+
+// CachedValidator 带缓存的 Token 验证器，TokenValidator 的装饰器
+// - 本地 LRU 缓存，TTL 受 token 过期时间约束
+// - 可选 Redis 共享缓存，多实例之间共享验证结果
+// - 同一 token 的并发验证通过 singleflight 合并为一次下游调用
+// - 通过 Revoke 吊销 token：清除缓存、写入本地和 Redis 吊销标记，并经 pub/sub 通知其他实例
+// 缓存和 Redis 中只保存 token 的 SHA-256 摘要，不保存原始 token
+type CachedValidator struct {
+	next   TokenValidator
+	config CacheConfig
+	rdb    *redis.Client
+	local  *lru.Cache[*UserClaims]
+	// revoked 本地吊销标记，保留到 token 过期；未配置 Redis 时是唯一的吊销依据
+	revoked *lru.Cache[struct{}]
+	group   singleflight.Group
+	log     *log.Helper
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewCachedValidator 创建带缓存的 Token 验证器
+// next: 实际执行验证的验证器，如 PassportTokenValidator
+// config: 缓存配置，为 nil 时使用默认配置
+// rdb: Redis 客户端，为 nil 时只使用本地缓存，且不支持跨实例吊销；
+// 配置 Redis 后吊销状态以 Redis 为准，Redis 不可用时验证失败（返回 ErrValidatorUnavailable）
+func NewCachedValidator(next TokenValidator, config *CacheConfig, rdb *redis.Client, logger log.Logger) *CachedValidator {
+	conf := CacheConfig{}
+	if config != nil {
+		conf = *config
+	}
+	if conf.Size <= 0 {
+		conf.Size = defaultCacheSize
+	}
+	if conf.TTL <= 0 {
+		conf.TTL = defaultCacheTTL
+	}
+	if conf.RevokedSize <= 0 {
+		conf.RevokedSize = defaultRevokedSize
+	}
+	if conf.RedisKeyPrefix == "" {
+		conf.RedisKeyPrefix = defaultRedisKeyPrefix
+	}
+	if conf.RevocationChannel == "" {
+		conf.RevocationChannel = defaultRevocationChannel
+	}
+
+	v := &CachedValidator{
+		next:    next,
+		config:  conf,
+		rdb:     rdb,
+		local:   lru.New[*UserClaims](conf.Size),
+		revoked: lru.New[struct{}](conf.RevokedSize),
+		log:     log.NewHelper(logger),
+	}
+
+	if rdb != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		v.cancel = cancel
+		v.done = make(chan struct{})
+		go v.subscribeRevocations(ctx)
+	}
+
+	return v
+}
+
+// ValidateToken 验证 token，优先使用缓存结果
+func (v *CachedValidator) ValidateToken(ctx context.Context, token string) (*UserClaims, error) {
+	key := tokenHash(token)
+	if v.isRevoked(key) {
+		return nil, ErrTokenRevoked
+	}
+	if claims, ok := v.local.Get(key, timeNow()); ok {
+		return copyClaims(claims), nil
+	}
+
+	// 合并的调用共享第一个调用方的 ctx，去掉取消信号，避免一个调用方取消导致其他调用方一起失败
+	result, err, _ := v.group.Do(key, func() (interface{}, error) {
+		return v.validate(context.WithoutCancel(ctx), key, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyClaims(result.(*UserClaims)), nil
+}
+
+// validate 缓存未命中时的验证流程：Redis 吊销标记 -> Redis 共享缓存 -> 下游验证器 -> 写回缓存
+// 无法确认 token 是否已被吊销时拒绝请求（fail closed），不读取共享缓存也不调用下游验证器
+func (v *CachedValidator) validate(ctx context.Context, key, token string) (*UserClaims, error) {
+	if v.rdb != nil {
+		revoked, err := v.rdb.Exists(ctx, v.revokedKey(key)).Result()
+		if err != nil {
+			v.log.Errorf("check token revocation failed: %v", err)
+			return nil, fmt.Errorf("check token revocation: %w: %w", ErrValidatorUnavailable, err)
+		}
+		if revoked > 0 {
+			return nil, ErrTokenRevoked
+		}
+
+		if claims, ttl, ok := v.getShared(ctx, key); ok {
+			if v.isRevoked(key) {
+				return nil, ErrTokenRevoked
+			}
+			v.local.Set(key, claims, timeNow().Add(ttl))
+			return claims, nil
+		}
+	}
+
+	claims, err := v.next.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证期间 token 可能已被吊销，写回缓存前再次检查，避免把已吊销的 token 重新写入缓存
+	if v.isRevoked(key) {
+		return nil, ErrTokenRevoked
+	}
+
+	ttl := v.cacheTTL(token, claims)
+	if ttl <= 0 {
+		return claims, nil
+	}
+	v.local.Set(key, claims, timeNow().Add(ttl))
+	if v.rdb != nil {
+		v.setShared(ctx, key, claims, ttl)
+	}
+	return claims, nil
+}
+
+// Revoke 吊销 token
+// 写入本地和 Redis 吊销标记（保留到 token 过期），清除本地和 Redis 缓存，并通知其他实例
+// 未配置 Redis 时吊销只是尽力而为：只在当前实例生效，且本地吊销标记数量受 CacheConfig.RevokedSize 限制，
+// 大量吊销时最久未使用的标记会被淘汰，对应的 token 在过期前会重新通过验证；需要可靠吊销时请配置 Redis
+func (v *CachedValidator) Revoke(ctx context.Context, token string) error {
+	key := tokenHash(token)
+
+	// 吊销标记需要保留到 token 过期；无法确定过期时间时使用缓存 TTL 上限
+	ttl := v.config.TTL
+	if expiresAt, ok := tokenExpiry(token); ok {
+		ttl = expiresAt.Sub(timeNow())
+	}
+
+	v.markRevoked(key, ttl)
+	if v.rdb == nil {
+		return nil
+	}
+
+	pipe := v.rdb.TxPipeline()
+	pipe.Del(ctx, v.config.RedisKeyPrefix+key)
+	if ttl > 0 {
+		pipe.Set(ctx, v.revokedKey(key), 1, ttl)
+	}
+	pipe.Publish(ctx, v.config.RevocationChannel, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("revoke token failed: %w", err)
+	}
+	return nil
+}
+
+// Close 停止吊销通知订阅
+func (v *CachedValidator) Close() error {
+	if v.cancel != nil {
+		v.cancel()
+		<-v.done
+	}
+	return nil
+}
+
+// subscribeRevocations 订阅吊销通知，收到后清除本地缓存
+func (v *CachedValidator) subscribeRevocations(ctx context.Context) {
+	defer close(v.done)
+
+	pubsub := v.rdb.Subscribe(ctx, v.config.RevocationChannel)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			// 其他实例吊销的 token：本地缓存条目最长存活 TTL，吊销标记保留相同时间即可拦截正在进行的验证
+			v.markRevoked(msg.Payload, v.config.TTL)
+		}
+	}
+}
+
+// markRevoked 写入本地吊销标记并清除本地缓存
+func (v *CachedValidator) markRevoked(key string, ttl time.Duration) {
+	if ttl > 0 {
+		v.revoked.Set(key, struct{}{}, timeNow().Add(ttl))
+	}
+	v.local.Delete(key)
+}
+
+// isRevoked 检查本地吊销标记
+func (v *CachedValidator) isRevoked(key string) bool {
+	_, ok := v.revoked.Get(key, timeNow())
+	return ok
+}
+
+// getShared 从 Redis 共享缓存读取验证结果
+func (v *CachedValidator) getShared(ctx context.Context, key string) (*UserClaims, time.Duration, bool) {
+	pipe := v.rdb.Pipeline()
+	getCmd := pipe.Get(ctx, v.config.RedisKeyPrefix+key)
+	ttlCmd := pipe.PTTL(ctx, v.config.RedisKeyPrefix+key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if !errors.Is(err, redis.Nil) {
+			v.log.Warnf("read token cache failed: %v", err)
+		}
+		return nil, 0, false
+	}
+
+	var claims UserClaims
+	if err := json.Unmarshal([]byte(getCmd.Val()), &claims); err != nil {
+		v.log.Warnf("decode token cache failed: %v", err)
+		return nil, 0, false
+	}
+
+	ttl := ttlCmd.Val()
+	if ttl <= 0 || ttl > v.config.TTL {
+		ttl = v.config.TTL
+	}
+	return &claims, ttl, true
+}
+
+// setShared 将验证结果写入 Redis 共享缓存
+func (v *CachedValidator) setShared(ctx context.Context, key string, claims *UserClaims, ttl time.Duration) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		v.log.Warnf("encode token cache failed: %v", err)
+		return
+	}
+	if err := v.rdb.Set(ctx, v.config.RedisKeyPrefix+key, data, ttl).Err(); err != nil {
+		v.log.Warnf("write token cache failed: %v", err)
+	}
+}
+
+// cacheTTL 计算缓存 TTL：不超过配置上限，也不超过 token 的过期时间
+// 过期时间优先使用验证器返回的 ExpiresAt，其次读取 JWT 的 exp 声明
+func (v *CachedValidator) cacheTTL(token string, claims *UserClaims) time.Duration {
+	ttl := v.config.TTL
+	expiresAt, ok := claims.ExpiresAt, !claims.ExpiresAt.IsZero()
+	if !ok {
+		expiresAt, ok = tokenExpiry(token)
+	}
+	if ok {
+		if remaining := expiresAt.Sub(timeNow()); remaining < ttl {
+			ttl = remaining
+		}
+	}
+	return ttl
+}
+
+// revokedKey 吊销标记的 Redis 键
+func (v *CachedValidator) revokedKey(key string) string {
+	return v.config.RedisKeyPrefix + "revoked:" + key
+}
+
+// tokenExpiry 读取 JWT 的 exp 声明（不验证签名，仅用于约束缓存 TTL）
+// 非 JWT 格式的 token 返回 false
+func tokenExpiry(token string) (time.Time, bool) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return time.Time{}, false
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return time.Time{}, false
+	}
+	return exp.Time, true
+}
+
+// tokenHash 计算 token 的 SHA-256 摘要，作为缓存键
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// copyClaims 复制用户声明，避免调用方修改缓存中的数据
+func copyClaims(claims *UserClaims) *UserClaims {
+	c := *claims
+	c.Roles = slices.Clone(claims.Roles)
+	c.Scopes = slices.Clone(claims.Scopes)
+	if claims.Custom != nil {
+		c.Custom = make(map[string]interface{}, len(claims.Custom))
+		for k, v := range claims.Custom {
+			c.Custom[k] = v
+		}
+	}
+	return &c
+}
+
+// timeNow 获取当前时间
+// 提取为变量方便测试时 mock
+var timeNow = time.Now
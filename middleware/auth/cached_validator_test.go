@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingValidator 记录调用次数的验证器
+func countingValidator(calls *int32, delay time.Duration) TokenValidator {
+	return TokenValidatorFunc(func(ctx context.Context, token string) (*UserClaims, error) {
+		atomic.AddInt32(calls, 1)
+		time.Sleep(delay)
+		return &UserClaims{UserID: "user-1", Role: "admin"}, nil
+	})
+}
+
+func TestCachedValidator_LocalCacheAndSingleflight(t *testing.T) {
+	var calls int32
+	validator := NewCachedValidator(countingValidator(&calls, 20*time.Millisecond), nil, nil, log.DefaultLogger)
+	defer validator.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claims, err := validator.ValidateToken(context.Background(), "opaque-token")
+			assert.NoError(t, err)
+			assert.Equal(t, "user-1", claims.UserID)
+		}()
+	}
+	wg.Wait()
+
+	_, err := validator.ValidateToken(context.Background(), "opaque-token")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestCachedValidator_TTLBoundedByExpiry(t *testing.T) {
+	var calls int32
+	validator := NewCachedValidator(countingValidator(&calls, 0), &CacheConfig{TTL: time.Hour}, nil, log.DefaultLogger)
+	defer validator.Close()
+
+	now := time.Now()
+	token := signToken(t, jwt.SigningMethodHS256, []byte("secret"), "", jwt.MapClaims{"sub": "user-1", "exp": now.Add(time.Minute).Unix()})
+
+	_, err := validator.ValidateToken(context.Background(), token)
+	require.NoError(t, err)
+
+	// token 过期后缓存也随之失效，不会沿用 1 小时的 TTL
+	timeNow = func() time.Time { return now.Add(2 * time.Minute) }
+	defer func() { timeNow = time.Now }()
+
+	_, err = validator.ValidateToken(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCachedValidator_RedisSharedAndRevoke(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	var calls int32
+	first := NewCachedValidator(countingValidator(&calls, 0), nil, rdb, log.DefaultLogger)
+	defer first.Close()
+	second := NewCachedValidator(countingValidator(&calls, 0), nil, rdb, log.DefaultLogger)
+	defer second.Close()
+
+	ctx := context.Background()
+	_, err := first.ValidateToken(ctx, "shared-token")
+	require.NoError(t, err)
+	_, err = second.ValidateToken(ctx, "shared-token")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "second instance should hit the redis tier")
+
+	require.NoError(t, first.Revoke(ctx, "shared-token"))
+
+	// 等待 pub/sub 通知第二个实例清除本地缓存
+	assert.Eventually(t, func() bool {
+		_, ok := second.local.Get(tokenHash("shared-token"), time.Now())
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = second.ValidateToken(ctx, "shared-token")
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestCachedValidator_RevokeWithoutRedis(t *testing.T) {
+	var calls int32
+	validator := NewCachedValidator(countingValidator(&calls, 0), nil, nil, log.DefaultLogger)
+	defer validator.Close()
+
+	ctx := context.Background()
+	_, err := validator.ValidateToken(ctx, "local-token")
+	require.NoError(t, err)
+
+	require.NoError(t, validator.Revoke(ctx, "local-token"))
+	_, err = validator.ValidateToken(ctx, "local-token")
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "revoked tokens must not reach the next validator")
+}
+
+func TestCachedValidator_RevocationCheckFailsClosed(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer rdb.Close()
+
+	var calls int32
+	validator := NewCachedValidator(countingValidator(&calls, 0), nil, rdb, log.DefaultLogger)
+	defer validator.Close()
+
+	// Redis 故障时无法确认 token 是否已被吊销，拒绝请求而不是放行
+	mr.SetError("LOADING redis is loading the dataset in memory")
+	_, err := validator.ValidateToken(context.Background(), "unchecked-token")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrValidatorUnavailable)
+	assert.Equal(t, FailureUnavailable, ClassifyError(err))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls), "unchecked tokens must not reach the next validator")
+
+	// Redis 恢复后正常验证
+	mr.SetError("")
+	_, err = validator.ValidateToken(context.Background(), "unchecked-token")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestCachedValidator_RevokedMarksSurviveCacheEviction(t *testing.T) {
+	var calls int32
+	validator := NewCachedValidator(countingValidator(&calls, 0), &CacheConfig{Size: 1}, nil, log.DefaultLogger)
+	defer validator.Close()
+
+	// 吊销标记不受验证缓存容量限制
+	ctx := context.Background()
+	tokens := []string{"revoked-1", "revoked-2", "revoked-3"}
+	for _, token := range tokens {
+		require.NoError(t, validator.Revoke(ctx, token))
+	}
+	for _, token := range tokens {
+		_, err := validator.ValidateToken(ctx, token)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	}
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+}
+
+func TestCachedValidator_RevokeDuringValidation(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	next := TokenValidatorFunc(func(ctx context.Context, token string) (*UserClaims, error) {
+		close(started)
+		<-release
+		return &UserClaims{UserID: "user-1"}, nil
+	})
+	validator := NewCachedValidator(next, nil, nil, log.DefaultLogger)
+	defer validator.Close()
+
+	ctx := context.Background()
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := validator.ValidateToken(ctx, "racing-token")
+		errCh <- err
+	}()
+
+	<-started
+	require.NoError(t, validator.Revoke(ctx, "racing-token"))
+	close(release)
+
+	assert.ErrorIs(t, <-errCh, ErrTokenRevoked)
+	_, ok := validator.local.Get(tokenHash("racing-token"), time.Now())
+	assert.False(t, ok, "in-flight validation must not repopulate the cache")
+}
+
+func TestCachedValidator_SharedCallIgnoresCallerCancel(t *testing.T) {
+	var once sync.Once
+	started := make(chan struct{})
+	release := make(chan struct{})
+	next := TokenValidatorFunc(func(ctx context.Context, token string) (*UserClaims, error) {
+		once.Do(func() { close(started) })
+		<-release
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return &UserClaims{UserID: "user-1"}, nil
+	})
+	validator := NewCachedValidator(next, nil, nil, log.DefaultLogger)
+	defer validator.Close()
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	go validator.ValidateToken(cancelCtx, "shared-call-token")
+	<-started
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := validator.ValidateToken(context.Background(), "shared-call-token")
+		errCh <- err
+	}()
+
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	assert.NoError(t, <-errCh)
+}
@@ -1,47 +1,179 @@
 // Package auth 提供认证中间件和工具函数
 package auth
 
-import "strings"
+import (
+	"github.com/gaoyong06/go-pkg/middleware/route"
+	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+)
 
 // Config 认证中间件配置
 type Config struct {
-	// 跳过认证的路径（支持通配符）
-	// 例如：["/health", "/swagger/*", "/v1/public/*"]
+	// 跳过认证的 Kratos operation（支持通配符、路径参数和正则，语法见 route 包）
+	// 例如：["/health", "/swagger/*", "/api.user.v1.User/Login", "/api.user.v1.Public/*"]
 	SkipPaths []string `json:"skip_paths" yaml:"skip_paths"`
+
+	// SkipPaths 是否同时按 HTTP "方法 + URL 路径" 匹配 HTTP 请求，默认 false（只匹配 Kratos operation）
+	// 开启后可以使用 "/v1/public/**"、"GET /v1/articles/{id}" 等 URL 路径模式；会扩大跳过认证的范围，需要确认模式不会匹配受保护的接口
+	MatchHTTPPath bool `json:"match_http_path" yaml:"match_http_path"`
+
+	// token 来源，按顺序尝试，可选 "header"、"cookie"、"query"，默认 ["header", "cookie"]
+	TokenSources []string `json:"token_sources" yaml:"token_sources"`
+
+	// 携带 token 的请求头，默认 "Authorization"，格式为 "<scheme> <credentials>"，scheme 不区分大小写
+	HeaderName string `json:"header_name" yaml:"header_name"`
+
+	// 携带 token 的 Cookie 名称，默认 "access_token"
+	CookieName string `json:"cookie_name" yaml:"cookie_name"`
+
+	// 携带 token 的 Query 参数（仅 HTTP），默认 "access_token"
+	QueryParam string `json:"query_param" yaml:"query_param"`
+
+	// CSRF 防护配置，token 来自 Cookie 时生效
+	CSRF CSRFConfig `json:"csrf" yaml:"csrf"`
+}
+
+// CSRFConfig CSRF 双重提交（double-submit cookie）校验配置
+// 启用后，token 来自 Cookie 且请求方法不是 GET/HEAD/OPTIONS/TRACE 时，
+// 要求请求头中的 CSRF token 与 Cookie 中的 CSRF token 一致
+type CSRFConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// 存放 CSRF token 的 Cookie，默认 "csrf_token"
+	CookieName string `json:"cookie_name" yaml:"cookie_name"`
+
+	// 提交 CSRF token 的请求头，默认 "X-CSRF-Token"
+	HeaderName string `json:"header_name" yaml:"header_name"`
+}
+
+// token 来源
+const (
+	TokenSourceHeader = "header"
+	TokenSourceCookie = "cookie"
+	TokenSourceQuery  = "query"
+)
+
+const (
+	defaultAuthHeaderName = "Authorization"
+	defaultTokenCookie    = "access_token"
+	defaultTokenQuery     = "access_token"
+	defaultCSRFCookie     = "csrf_token"
+	defaultCSRFHeader     = "X-CSRF-Token"
+)
+
+// defaultTokenSources 默认 token 来源
+var defaultTokenSources = []string{TokenSourceHeader, TokenSourceCookie}
+
+// withDefaults 返回填充了默认值的配置副本，config 为 nil 时返回默认配置
+func (c *Config) withDefaults() *Config {
+	conf := Config{}
+	if c != nil {
+		conf = *c
+	}
+	if len(conf.TokenSources) == 0 {
+		conf.TokenSources = defaultTokenSources
+	}
+	if conf.HeaderName == "" {
+		conf.HeaderName = defaultAuthHeaderName
+	}
+	if conf.CookieName == "" {
+		conf.CookieName = defaultTokenCookie
+	}
+	if conf.QueryParam == "" {
+		conf.QueryParam = defaultTokenQuery
+	}
+	if conf.CSRF.CookieName == "" {
+		conf.CSRF.CookieName = defaultCSRFCookie
+	}
+	if conf.CSRF.HeaderName == "" {
+		conf.CSRF.HeaderName = defaultCSRFHeader
+	}
+	return &conf
 }
 
-// ShouldSkipPath 判断是否应该跳过某个路径
+// ShouldSkipPath 判断是否应该跳过某个路径或 Kratos operation
+// 模式语法见 route 包；限定了 HTTP 方法的模式需要使用 ShouldSkipRequest
 func (c *Config) ShouldSkipPath(path string) bool {
 	if c == nil {
 		return false
 	}
+	return route.MatchAny("", path, c.SkipPaths)
+}
 
-	for _, skipPath := range c.SkipPaths {
-		if MatchPath(path, skipPath) {
-			return true
+// ShouldSkipRequest 判断是否应该跳过当前请求
+// 匹配 Kratos operation；开启 MatchHTTPPath 时，HTTP 请求还会按 "方法 + URL 路径" 匹配
+// 中间件使用创建时预编译的规则，与该方法的匹配结果一致
+func (c *Config) ShouldSkipRequest(tr transport.Transporter) bool {
+	if c == nil || len(c.SkipPaths) == 0 {
+		return false
+	}
+	if route.MatchAny("", tr.Operation(), c.SkipPaths) {
+		return true
+	}
+	if method, path, ok := httpRequestPath(tr); ok && c.MatchHTTPPath {
+		return route.MatchAny(method, path, c.SkipPaths)
+	}
+	return false
+}
+
+// skipper 预编译的跳过规则，中间件创建时构造，避免每个请求查询全局模式缓存
+type skipper struct {
+	rules         []*route.Rule
+	matchHTTPPath bool
+}
+
+// newSkipper 编译 SkipPaths，无效的模式不匹配任何请求（与 ShouldSkipRequest 一致）
+func (c *Config) newSkipper() *skipper {
+	s := &skipper{}
+	if c == nil {
+		return s
+	}
+	s.matchHTTPPath = c.MatchHTTPPath
+	for _, pattern := range c.SkipPaths {
+		if rule, err := route.Compile(pattern); err == nil {
+			s.rules = append(s.rules, rule)
 		}
 	}
+	return s
+}
 
+// skip 判断是否应该跳过当前请求
+func (s *skipper) skip(tr transport.Transporter) bool {
+	if len(s.rules) == 0 {
+		return false
+	}
+	if s.match("", tr.Operation()) {
+		return true
+	}
+	if method, path, ok := httpRequestPath(tr); ok && s.matchHTTPPath {
+		return s.match(method, path)
+	}
 	return false
 }
 
-// MatchPath 匹配路径（支持简单的通配符）
-// 支持格式：
-// - "/path" - 精确匹配
-// - "/path/*" - 前缀匹配
-// - "*/suffix" - 后缀匹配
-func MatchPath(path, pattern string) bool {
-	// 简单的通配符匹配实现
-	if strings.HasSuffix(pattern, "*") {
-		prefix := strings.TrimSuffix(pattern, "*")
-		return strings.HasPrefix(path, prefix)
+// match 判断请求是否匹配任意规则
+func (s *skipper) match(method, path string) bool {
+	for _, rule := range s.rules {
+		if rule.Match(method, path) {
+			return true
+		}
 	}
+	return false
+}
 
-	if strings.HasPrefix(pattern, "*") {
-		suffix := strings.TrimPrefix(pattern, "*")
-		return strings.HasSuffix(path, suffix)
+// httpRequestPath 获取 HTTP 请求的方法和 URL 路径，非 HTTP 请求返回 false
+func httpRequestPath(tr transport.Transporter) (string, string, bool) {
+	httpTr, ok := tr.(*kratoshttp.Transport)
+	if !ok || httpTr.Request() == nil {
+		return "", "", false
 	}
-
-	return path == pattern
+	r := httpTr.Request()
+	return r.Method, r.URL.Path, true
 }
 
+// MatchPath 匹配路径（支持通配符、路径参数和正则，语法见 route 包）
+//
+// Deprecated: 使用 route.Match
+func MatchPath(path, pattern string) bool {
+	return route.Match(path, pattern)
+}
@@ -1,7 +1,10 @@
 // Package auth 提供认证中间件和工具函数
 package auth
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // userClaimsKey 是 context 中存储用户声明信息的键
 type userClaimsKey struct{}
@@ -11,8 +14,60 @@ var UserClaimsKey = userClaimsKey{}
 
 // UserClaims 用户声明信息
 type UserClaims struct {
-	UserID string
-	Role   string
+	UserID    string                 `json:"user_id"`
+	Role      string                 `json:"role"`                // 主角色（兼容单角色场景）
+	Roles     []string               `json:"roles,omitempty"`     // 全部角色
+	Scopes    []string               `json:"scopes,omitempty"`    // 授权范围/权限，如 "order:read"
+	TenantID  string                 `json:"tenant_id,omitempty"` // 租户/应用 ID
+	SessionID string                 `json:"session_id,omitempty"`
+	ExpiresAt time.Time              `json:"expires_at,omitzero"` // token 过期时间，零值表示未知
+	Custom    map[string]interface{} `json:"custom,omitempty"`    // 其他自定义声明
+}
+
+// HasRole 是否拥有指定角色（匹配 Role 或 Roles）
+func (c *UserClaims) HasRole(role string) bool {
+	if c == nil || role == "" {
+		return false
+	}
+	if c.Role == role {
+		return true
+	}
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope 是否拥有指定授权范围
+func (c *UserClaims) HasScope(scope string) bool {
+	if c == nil || scope == "" {
+		return false
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllRoles 返回去重后的全部角色（Role 在前）
+func (c *UserClaims) AllRoles() []string {
+	if c == nil {
+		return nil
+	}
+	roles := make([]string, 0, len(c.Roles)+1)
+	if c.Role != "" {
+		roles = append(roles, c.Role)
+	}
+	for _, r := range c.Roles {
+		if r != "" && r != c.Role {
+			roles = append(roles, r)
+		}
+	}
+	return roles
 }
 
 // WithUserClaims 将用户声明信息存入 context
@@ -43,8 +98,3 @@ func GetUserRoleFromContext(ctx context.Context) string {
 	}
 	return claims.Role
 }
-
-
-
-
-
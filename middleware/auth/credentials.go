@@ -0,0 +1,181 @@
+// Package auth 提供认证中间件和工具函数
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// 认证方案
+const (
+	SchemeBearer = "Bearer"
+	SchemeBasic  = "Basic"
+)
+
+// credentials 从请求中提取的认证凭证
+type credentials struct {
+	scheme   string // SchemeBearer 或 SchemeBasic
+	source   string // TokenSourceHeader、TokenSourceCookie 或 TokenSourceQuery
+	token    string
+	username string
+	password string
+}
+
+// BasicValidator Basic 认证验证器，用于内部工具等无法使用 token 的场景
+type BasicValidator interface {
+	// ValidateBasic 验证用户名和密码，返回用户声明信息
+	ValidateBasic(ctx context.Context, username, password string) (*UserClaims, error)
+}
+
+// BasicUser 静态 Basic 认证用户
+type BasicUser struct {
+	Password string   `json:"password" yaml:"password"`
+	Roles    []string `json:"roles" yaml:"roles"`
+}
+
+// StaticBasicValidator 基于固定用户列表的 Basic 认证验证器
+// 密码应通过配置中心或密钥管理注入，不要写入代码仓库
+type StaticBasicValidator struct {
+	users map[string]*BasicUser
+}
+
+// NewStaticBasicValidator 创建静态 Basic 认证验证器
+// users: 用户名 -> 用户信息
+func NewStaticBasicValidator(users map[string]*BasicUser) *StaticBasicValidator {
+	return &StaticBasicValidator{users: users}
+}
+
+// ValidateBasic 验证用户名和密码（常量时间比较）
+func (v *StaticBasicValidator) ValidateBasic(ctx context.Context, username, password string) (*UserClaims, error) {
+	user, ok := v.users[username]
+	expected := ""
+	if ok && user != nil {
+		expected = user.Password
+	}
+	got := sha256.Sum256([]byte(password))
+	want := sha256.Sum256([]byte(expected))
+	if !ok || user == nil || expected == "" || subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	claims := &UserClaims{UserID: username, Roles: append([]string(nil), user.Roles...)}
+	if len(user.Roles) > 0 {
+		claims.Role = user.Roles[0]
+	}
+	return claims, nil
+}
+
+// extractCredentials 按配置的来源顺序提取认证凭证
+// 没有凭证时返回 FailureMissing，格式错误时返回 FailureMalformed，CSRF 校验失败时返回 FailureCSRF
+func extractCredentials(tr transport.Transporter, conf *Config) (*credentials, FailureReason) {
+	for _, source := range conf.TokenSources {
+		switch source {
+		case TokenSourceHeader:
+			value := strings.TrimSpace(tr.RequestHeader().Get(conf.HeaderName))
+			if value == "" {
+				continue
+			}
+			return parseAuthorization(value)
+
+		case TokenSourceCookie:
+			token := requestCookie(tr, conf.CookieName)
+			if token == "" {
+				continue
+			}
+			if conf.CSRF.Enabled && !checkCSRF(tr, &conf.CSRF) {
+				return nil, FailureCSRF
+			}
+			return &credentials{scheme: SchemeBearer, source: TokenSourceCookie, token: token}, ""
+
+		case TokenSourceQuery:
+			httpTr, ok := tr.(*kratoshttp.Transport)
+			if !ok || httpTr.Request() == nil {
+				continue
+			}
+			if token := strings.TrimSpace(httpTr.Request().URL.Query().Get(conf.QueryParam)); token != "" {
+				return &credentials{scheme: SchemeBearer, source: TokenSourceQuery, token: token}, ""
+			}
+		}
+	}
+	return nil, FailureMissing
+}
+
+// parseAuthorization 解析 Authorization 请求头，scheme 不区分大小写，允许多余空白
+func parseAuthorization(value string) (*credentials, FailureReason) {
+	scheme, param, ok := strings.Cut(value, " ")
+	param = strings.TrimSpace(param)
+	if !ok || param == "" {
+		return nil, FailureMalformed
+	}
+
+	switch {
+	case strings.EqualFold(scheme, SchemeBearer):
+		if strings.ContainsAny(param, " \t") {
+			return nil, FailureMalformed
+		}
+		return &credentials{scheme: SchemeBearer, source: TokenSourceHeader, token: param}, ""
+
+	case strings.EqualFold(scheme, SchemeBasic):
+		decoded, err := base64.StdEncoding.DecodeString(param)
+		if err != nil {
+			return nil, FailureMalformed
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok || username == "" {
+			return nil, FailureMalformed
+		}
+		return &credentials{scheme: SchemeBasic, source: TokenSourceHeader, username: username, password: password}, ""
+
+	default:
+		return nil, FailureMalformed
+	}
+}
+
+// requestCookie 读取 Cookie，HTTP 请求使用标准库解析，gRPC 请求解析 metadata 中的 cookie
+func requestCookie(tr transport.Transporter, name string) string {
+	if httpTr, ok := tr.(*kratoshttp.Transport); ok && httpTr.Request() != nil {
+		cookie, err := httpTr.Request().Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(cookie.Value)
+	}
+
+	line := strings.TrimSpace(tr.RequestHeader().Get("Cookie"))
+	if line == "" {
+		return ""
+	}
+	cookies, err := http.ParseCookie(line)
+	if err != nil {
+		return ""
+	}
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return strings.TrimSpace(cookie.Value)
+		}
+	}
+	return ""
+}
+
+// checkCSRF CSRF 双重提交校验，只对 HTTP 非安全方法生效
+func checkCSRF(tr transport.Transporter, conf *CSRFConfig) bool {
+	httpTr, ok := tr.(*kratoshttp.Transport)
+	if !ok || httpTr.Request() == nil {
+		return true
+	}
+	switch httpTr.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	cookieToken := requestCookie(tr, conf.CookieName)
+	headerToken := strings.TrimSpace(tr.RequestHeader().Get(conf.HeaderName))
+	return cookieToken != "" && subtle.ConstantTimeCompare([]byte(cookieToken), []byte(headerToken)) == 1
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuthorization(t *testing.T) {
+	basic := base64.StdEncoding.EncodeToString([]byte("ops:pa:ss"))
+
+	tests := []struct {
+		value  string
+		want   *credentials
+		reason FailureReason
+	}{
+		{"Bearer abc", &credentials{scheme: SchemeBearer, source: TokenSourceHeader, token: "abc"}, ""},
+		{"bearer   abc ", &credentials{scheme: SchemeBearer, source: TokenSourceHeader, token: "abc"}, ""},
+		{"BASIC " + basic, &credentials{scheme: SchemeBasic, source: TokenSourceHeader, username: "ops", password: "pa:ss"}, ""},
+		{"Bearer", nil, FailureMalformed},
+		{"Bearer a b", nil, FailureMalformed},
+		{"Basic !!!", nil, FailureMalformed},
+		{"Digest abc", nil, FailureMalformed},
+	}
+	for _, tt := range tests {
+		got, reason := parseAuthorization(tt.value)
+		assert.Equal(t, tt.reason, reason, tt.value)
+		assert.Equal(t, tt.want, got, tt.value)
+	}
+}
+
+func TestExtractCredentials_Sources(t *testing.T) {
+	conf := (&Config{TokenSources: []string{TokenSourceCookie, TokenSourceHeader}, CookieName: "sid"}).withDefaults()
+
+	tr := &testTransport{header: headerCarrier{"Cookie": "theme=dark; sid=cookie-token", "Authorization": "Bearer header-token"}}
+	creds, reason := extractCredentials(tr, conf)
+	require.Empty(t, reason)
+	assert.Equal(t, "cookie-token", creds.token)
+	assert.Equal(t, TokenSourceCookie, creds.source)
+
+	tr = &testTransport{header: headerCarrier{"Authorization": "Bearer header-token"}}
+	creds, _ = extractCredentials(tr, conf)
+	assert.Equal(t, "header-token", creds.token)
+
+	_, reason = extractCredentials(&testTransport{header: headerCarrier{}}, conf)
+	assert.Equal(t, FailureMissing, reason)
+}
+
+func TestMiddleware_BasicAuth(t *testing.T) {
+	basic := NewStaticBasicValidator(map[string]*BasicUser{"ops": {Password: "s3cret", Roles: []string{"admin"}}})
+	validator := TokenValidatorFunc(func(ctx context.Context, token string) (*UserClaims, error) {
+		return nil, ErrTokenRevoked
+	})
+
+	var got *UserClaims
+	handler := Middleware(validator, nil, log.DefaultLogger, WithBasicAuth(basic))(
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			got, _ = GetUserClaimsFromContext(ctx)
+			return nil, nil
+		})
+
+	call := func(user, password string) {
+		got = nil
+		value := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+		ctx := transport.NewServerContext(context.Background(), &testTransport{header: headerCarrier{"Authorization": value}})
+		_, err := handler(ctx, nil)
+		require.NoError(t, err)
+	}
+
+	call("ops", "s3cret")
+	require.NotNil(t, got)
+	assert.Equal(t, "ops", got.UserID)
+	assert.True(t, got.HasRole("admin"))
+
+	call("ops", "wrong")
+	assert.Nil(t, got)
+}
+
+func TestMiddleware_CSRF(t *testing.T) {
+	validator := TokenValidatorFunc(func(ctx context.Context, token string) (*UserClaims, error) {
+		return &UserClaims{UserID: token}, nil
+	})
+	config := &Config{CSRF: CSRFConfig{Enabled: true}}
+
+	srv := kratoshttp.NewServer(kratoshttp.Middleware(
+		Middleware(validator, config, log.DefaultLogger),
+		RequireAuth(validator, config, log.DefaultLogger),
+	))
+	handle := func(ctx kratoshttp.Context) error {
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return GetUserIDFromContext(ctx), nil
+		})
+		out, err := h(ctx, nil)
+		if err != nil {
+			// 业务错误码不是合法的 HTTP 状态码，服务中由 response 包的错误编码器处理
+			return ctx.String(http.StatusForbidden, err.Error())
+		}
+		return ctx.String(http.StatusOK, out.(string))
+	}
+	srv.Route("/").GET("/profile", handle)
+	srv.Route("/").POST("/profile", handle)
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	do := func(method string, csrfHeader string, bearer bool) int {
+		req, err := http.NewRequest(method, server.URL+"/profile", nil)
+		require.NoError(t, err)
+		if bearer {
+			req.Header.Set("Authorization", "Bearer user-1")
+		} else {
+			req.AddCookie(&http.Cookie{Name: "access_token", Value: "user-1"})
+			req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "csrf-1"})
+		}
+		if csrfHeader != "" {
+			req.Header.Set("X-CSRF-Token", csrfHeader)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "", false))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "csrf-1", false))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "", true))
+	assert.NotEqual(t, http.StatusOK, do(http.MethodPost, "", false))
+	assert.NotEqual(t, http.StatusOK, do(http.MethodPost, "other", false))
+
+	// RequireAuth 对 CSRF 失败返回 ErrCodeForbidden
+	ctx := WithAuthFailure(context.Background(), FailureCSRF)
+	err := newMiddlewareOptions(nil).unauthenticated(ctx)
+	assert.Equal(t, int32(pkgErrors.ErrCodeForbidden), kratosErrors.FromError(err).Code)
+}
+
+func TestSkipPathsHTTPPathOptIn(t *testing.T) {
+	validator := TokenValidatorFunc(func(ctx context.Context, token string) (*UserClaims, error) {
+		return &UserClaims{UserID: token}, nil
+	})
+
+	status := func(config *Config) int {
+		srv := kratoshttp.NewServer(kratoshttp.Middleware(RequireAuth(validator, config, log.DefaultLogger)))
+		srv.Route("/").GET("/v1/public/info", func(ctx kratoshttp.Context) error {
+			// 与 protoc-gen-go-http 生成的代码一致，operation 为 proto 方法名
+			kratoshttp.SetOperation(ctx, "/api.info.v1.Info/Get")
+			h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, nil
+			})
+			if _, err := h(ctx, nil); err != nil {
+				return ctx.String(http.StatusUnauthorized, err.Error())
+			}
+			return ctx.String(http.StatusOK, "ok")
+		})
+		server := httptest.NewServer(srv)
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/v1/public/info")
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// 默认只匹配 Kratos operation，URL 路径模式不会跳过认证
+	assert.Equal(t, http.StatusUnauthorized, status(&Config{SkipPaths: []string{"/v1/public/**"}}))
+	assert.Equal(t, http.StatusOK, status(&Config{SkipPaths: []string{"/v1/public/**"}, MatchHTTPPath: true}))
+	assert.Equal(t, http.StatusOK, status(&Config{SkipPaths: []string{"/api.info.v1.Info/*"}}))
+}
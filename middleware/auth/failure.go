@@ -0,0 +1,251 @@
+// Package auth 提供认证中间件和工具函数
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/i18n"
+	"github.com/gaoyong06/go-pkg/utils"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrValidatorUnavailable Token 验证器暂不可用（如 passport-service 或 JWKS 端点无法访问）
+	ErrValidatorUnavailable = errors.New("token validator unavailable")
+	// ErrInvalidCredentials Basic 认证用户名或密码错误
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrUnsupportedScheme 不支持的认证方案（如未启用 Basic 认证）
+	ErrUnsupportedScheme = errors.New("unsupported authorization scheme")
+)
+
+// Token 验证失败的错误原因（ErrorInfo.Reason）
+// 业务错误码经过 gRPC 传输后会被映射为 HTTP 状态码，只有 Reason 能原样到达调用方，
+// passport-service 等远程验证器应使用这些 Reason 返回 token 过期和无效
+const (
+	// ReasonTokenExpired token 已过期
+	ReasonTokenExpired = "TOKEN_EXPIRED"
+	// ReasonTokenInvalid token 无效
+	ReasonTokenInvalid = "TOKEN_INVALID"
+)
+
+// FailureReason 认证失败原因
+type FailureReason string
+
+const (
+	// FailureMissing 请求中没有 token
+	FailureMissing FailureReason = "missing"
+	// FailureMalformed token 格式错误（如 Authorization 不是 Bearer 格式、JWT 无法解析）
+	FailureMalformed FailureReason = "malformed"
+	// FailureExpired token 已过期
+	FailureExpired FailureReason = "expired"
+	// FailureRevoked token 已被吊销
+	FailureRevoked FailureReason = "revoked"
+	// FailureInvalid token 无效（签名错误、声明不匹配等）
+	FailureInvalid FailureReason = "invalid"
+	// FailureUnavailable 验证器暂不可用，无法判断 token 是否有效
+	FailureUnavailable FailureReason = "unavailable"
+	// FailureForbidden 已认证但权限不足
+	FailureForbidden FailureReason = "forbidden"
+	// FailureCSRF token 来自 Cookie，但 CSRF token 缺失或不匹配
+	FailureCSRF FailureReason = "csrf"
+)
+
+// ErrorCode 失败原因对应的错误码
+// 过期返回 ErrCodeTokenExpired，格式错误/无效/已吊销返回 ErrCodeTokenInvalid，
+// 权限不足或 CSRF 校验失败返回 ErrCodeForbidden，其余返回 ErrCodeUnauthorized
+func (r FailureReason) ErrorCode() int32 {
+	switch r {
+	case FailureExpired:
+		return pkgErrors.ErrCodeTokenExpired
+	case FailureMalformed, FailureInvalid, FailureRevoked:
+		return pkgErrors.ErrCodeTokenInvalid
+	case FailureForbidden, FailureCSRF:
+		return pkgErrors.ErrCodeForbidden
+	default:
+		return pkgErrors.ErrCodeUnauthorized
+	}
+}
+
+// ClassifyError 根据验证器返回的错误判断失败原因
+// 支持 JWTValidator（jwt 库错误）、CachedValidator（ErrTokenRevoked）、
+// PassportTokenValidator（gRPC 状态码和错误原因 ReasonTokenExpired/ReasonTokenInvalid），
+// 以及进程内验证器返回的 ErrCodeTokenExpired/ErrCodeTokenInvalid 业务错误
+func ClassifyError(err error) FailureReason {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrTokenRevoked):
+		return FailureRevoked
+	case errors.Is(err, ErrValidatorUnavailable),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, context.Canceled):
+		return FailureUnavailable
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return FailureExpired
+	case errors.Is(err, jwt.ErrTokenMalformed), errors.Is(err, ErrUnsupportedScheme):
+		return FailureMalformed
+	}
+
+	if se := kratosErrors.FromError(err); se != nil {
+		switch {
+		case se.Reason == ReasonTokenExpired, se.Code == pkgErrors.ErrCodeTokenExpired:
+			return FailureExpired
+		case se.Reason == ReasonTokenInvalid, se.Code == pkgErrors.ErrCodeTokenInvalid:
+			return FailureInvalid
+		}
+	}
+
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+			return FailureUnavailable
+		}
+	}
+
+	return FailureInvalid
+}
+
+// authFailureKey 是 context 中存储认证失败原因的键
+type authFailureKey struct{}
+
+// AuthFailureKey 导出认证失败原因键，供外部使用
+var AuthFailureKey = authFailureKey{}
+
+// WithAuthFailure 将认证失败原因存入 context
+func WithAuthFailure(ctx context.Context, reason FailureReason) context.Context {
+	return context.WithValue(ctx, AuthFailureKey, reason)
+}
+
+// GetAuthFailureFromContext 获取 Middleware 记录的认证失败原因
+// 请求携带了 token 但验证失败时才有值，匿名请求返回空字符串
+func GetAuthFailureFromContext(ctx context.Context) FailureReason {
+	reason, _ := ctx.Value(AuthFailureKey).(FailureReason)
+	return reason
+}
+
+// AuditEvent 认证失败审计事件
+type AuditEvent struct {
+	Time      time.Time
+	Operation string
+	Reason    FailureReason
+	ClientIP  string
+	UserAgent string
+	UserID    string // 已认证但权限不足时的用户 ID
+	Err       error  // 验证器返回的原始错误
+}
+
+// AuditHook 审计事件回调，如写入审计日志或上报安全告警
+// 在请求处理流程中同步调用，耗时操作应自行异步处理
+type AuditHook func(ctx context.Context, event *AuditEvent)
+
+// MiddlewareOption 认证中间件选项
+type MiddlewareOption func(*middlewareOptions)
+
+// middlewareOptions 认证中间件可选配置
+type middlewareOptions struct {
+	rejectInvalid  bool
+	auditHook      AuditHook
+	errorManager   *pkgErrors.ErrorManager
+	basicValidator BasicValidator
+}
+
+// WithRejectInvalidToken 携带了 token 但验证失败时直接拒绝请求，而不是降级为匿名访问
+// 未携带 token 的请求仍然放行，由 RequireAuth 决定是否需要认证
+func WithRejectInvalidToken() MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.rejectInvalid = true
+	}
+}
+
+// WithAuditHook 设置认证失败审计回调
+func WithAuditHook(hook AuditHook) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.auditHook = hook
+	}
+}
+
+// WithErrorMessages 使用 ErrorManager 生成本地化的错误（文案来自服务的 errors.json）
+func WithErrorMessages(manager *pkgErrors.ErrorManager) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.errorManager = manager
+	}
+}
+
+// WithBasicAuth 启用 Basic 认证（Authorization: Basic ...），用于内部工具
+// 未启用时 Basic 凭证视为格式错误
+func WithBasicAuth(validator BasicValidator) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.basicValidator = validator
+	}
+}
+
+// newMiddlewareOptions 应用中间件选项
+func newMiddlewareOptions(opts []MiddlewareOption) *middlewareOptions {
+	o := &middlewareOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// audit 触发审计回调
+func (o *middlewareOptions) audit(ctx context.Context, reason FailureReason, err error) {
+	if o.auditHook == nil {
+		return
+	}
+
+	event := &AuditEvent{
+		Time:      timeNow(),
+		Reason:    reason,
+		ClientIP:  utils.GetClientIPRaw(ctx),
+		UserAgent: utils.GetUserAgent(ctx),
+		UserID:    GetUserIDFromContext(ctx),
+		Err:       err,
+	}
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		event.Operation = tr.Operation()
+	}
+	o.auditHook(ctx, event)
+}
+
+// unauthenticated 未认证时的错误，优先使用 Middleware 记录的失败原因
+// token 验证失败已由 Middleware 审计，这里只审计未携带 token 的情况
+func (o *middlewareOptions) unauthenticated(ctx context.Context) error {
+	reason := GetAuthFailureFromContext(ctx)
+	if reason == "" {
+		reason = FailureMissing
+		o.audit(ctx, reason, nil)
+	}
+	return newAuthError(ctx, o.errorManager, reason.ErrorCode())
+}
+
+// authMessages 未配置 ErrorManager 时使用的内置文案
+var authMessages = pkgErrors.Messages{
+	"zh-CN": {
+		pkgErrors.ErrCodeUnauthorized:    "请先登录",
+		pkgErrors.ErrCodeForbidden:       "没有权限执行该操作",
+		pkgErrors.ErrCodeTokenExpired:    "登录已过期，请重新登录",
+		pkgErrors.ErrCodeTokenInvalid:    "登录凭证无效，请重新登录",
+		http.StatusRequestEntityTooLarge: "请求内容过大",
+	},
+	"en-US": {
+		pkgErrors.ErrCodeUnauthorized:    "Authentication required",
+		pkgErrors.ErrCodeForbidden:       "You do not have permission to perform this operation",
+		pkgErrors.ErrCodeTokenExpired:    "Your session has expired, please sign in again",
+		pkgErrors.ErrCodeTokenInvalid:    "Invalid credentials, please sign in again",
+		http.StatusRequestEntityTooLarge: "Request body too large",
+	},
+}
+
+// newAuthError 创建本地化的认证/授权业务错误
+func newAuthError(ctx context.Context, manager *pkgErrors.ErrorManager, code int32) *kratosErrors.Error {
+	return pkgErrors.NewBizErrorWithFallback(ctx, manager, authMessages, i18n.Language(ctx), code)
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want FailureReason
+	}{
+		{fmt.Errorf("validate token failed: %w", jwt.ErrTokenExpired), FailureExpired},
+		{fmt.Errorf("validate token failed: %w", jwt.ErrTokenMalformed), FailureMalformed},
+		{fmt.Errorf("validate token failed: %w", jwt.ErrTokenSignatureInvalid), FailureInvalid},
+		{ErrTokenRevoked, FailureRevoked},
+		{fmt.Errorf("validate token failed: %w", status.Error(codes.Unavailable, "connection refused")), FailureUnavailable},
+		{fmt.Errorf("validate token failed: %w", context.DeadlineExceeded), FailureUnavailable},
+		{kratosErrors.New(pkgErrors.ErrCodeTokenExpired, "BIZ_ERROR", "expired"), FailureExpired},
+		{kratosErrors.Unauthorized(ReasonTokenExpired, "expired"), FailureExpired},
+		{kratosErrors.Unauthorized(ReasonTokenInvalid, "invalid"), FailureInvalid},
+		{errors.New("unknown"), FailureInvalid},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ClassifyError(tt.err), tt.err.Error())
+	}
+}
+
+func TestMiddleware_FailureReasons(t *testing.T) {
+	validator, err := NewJWTValidator(&JWTConfig{HMACSecret: "secret"}, log.DefaultLogger)
+	require.NoError(t, err)
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	expiredToken := signToken(t, jwt.SigningMethodHS256, []byte("secret"), "", expired)
+
+	var events []*AuditEvent
+	audit := WithAuditHook(func(ctx context.Context, event *AuditEvent) {
+		events = append(events, event)
+	})
+	ok := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	call := func(h func(context.Context, interface{}) (interface{}, error), header headerCarrier) error {
+		ctx := transport.NewServerContext(context.Background(), &testTransport{header: header, operation: "/api.v1.Svc/Get"})
+		_, err := h(ctx, nil)
+		return err
+	}
+
+	// 默认降级为匿名访问，RequireAuth 根据失败原因返回错误码
+	chain := Middleware(validator, nil, log.DefaultLogger, audit)(RequireAuth(validator, nil, log.DefaultLogger, audit)(ok))
+
+	err = call(chain, headerCarrier{"Authorization": "Bearer " + expiredToken, "User-Agent": "curl/8", "X-Real-IP": "10.0.0.1"})
+	assert.Equal(t, int32(pkgErrors.ErrCodeTokenExpired), kratosErrors.FromError(err).Code)
+	require.Len(t, events, 1)
+	assert.Equal(t, FailureExpired, events[0].Reason)
+	assert.Equal(t, "10.0.0.1", events[0].ClientIP)
+	assert.Equal(t, "curl/8", events[0].UserAgent)
+	assert.Equal(t, "/api.v1.Svc/Get", events[0].Operation)
+
+	err = call(chain, headerCarrier{"Authorization": "Token abc"})
+	assert.Equal(t, int32(pkgErrors.ErrCodeTokenInvalid), kratosErrors.FromError(err).Code)
+	assert.Equal(t, FailureMalformed, events[1].Reason)
+
+	err = call(chain, headerCarrier{})
+	assert.Equal(t, int32(pkgErrors.ErrCodeUnauthorized), kratosErrors.FromError(err).Code)
+	assert.Equal(t, FailureMissing, events[2].Reason)
+	assert.Len(t, events, 3)
+
+	// 匿名访问允许时，无效 token 降级为匿名
+	assert.NoError(t, call(Middleware(validator, nil, log.DefaultLogger)(ok), headerCarrier{"Authorization": "Bearer " + expiredToken}))
+
+	// 拒绝无效 token
+	reject := Middleware(validator, nil, log.DefaultLogger, WithRejectInvalidToken())(ok)
+	err = call(reject, headerCarrier{"Authorization": "Bearer " + expiredToken})
+	assert.Equal(t, int32(pkgErrors.ErrCodeTokenExpired), kratosErrors.FromError(err).Code)
+	assert.NoError(t, call(reject, headerCarrier{}))
+}
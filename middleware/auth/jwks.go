@@ -0,0 +1,273 @@
+// Package auth 提供认证中间件和工具函数
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// jwk JSON Web Key（RFC 7517），仅包含验证签名所需的字段
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// RSA 公钥
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC 公钥
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+
+	// 对称密钥
+	K string `json:"k"`
+}
+
+// jwkSet JSON Web Key Set
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// parseJWKS 解析 JWKS，返回 kid -> 公钥（*rsa.PublicKey、*ecdsa.PublicKey 或 []byte）
+// 用途不是签名验证（use != "sig"）的密钥会被忽略
+func parseJWKS(data []byte) (map[string]interface{}, error) {
+	var set jwkSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("parse jwk %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+// publicKey 将 JWK 转换为验证签名使用的密钥
+func (k *jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, fmt.Errorf("invalid symmetric key: %w", err)
+		}
+		return secret, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+// decodeBigInt 解码 base64url 编码的大整数
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// jwksMinRefreshInterval 遇到未知 kid 时两次刷新之间的最小间隔，避免伪造 kid 导致频繁拉取
+const jwksMinRefreshInterval = 10 * time.Second
+
+// jwksSource JWKS 密钥来源（本地文件或 HTTP 端点），支持定期和按需刷新
+type jwksSource struct {
+	file            string
+	url             string
+	refreshInterval time.Duration
+	client          *http.Client
+
+	group      singleflight.Group
+	refreshing atomic.Bool
+
+	mutex       sync.RWMutex
+	keys        map[string]interface{}
+	attemptedAt time.Time // 最近一次刷新尝试的时间（无论成功与否）
+}
+
+// newJWKSSource 创建 JWKS 密钥来源并立即加载一次
+func newJWKSSource(ctx context.Context, file, url string, refreshInterval time.Duration) (*jwksSource, error) {
+	s := &jwksSource{
+		file:            file,
+		url:             url,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: 5 * time.Second},
+	}
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// key 根据 kid 获取密钥
+// 密钥过期（超过刷新间隔）时在后台刷新，刷新期间和刷新失败后继续使用当前密钥，下一个间隔再重试；
+// kid 未知（如密钥轮换）时同步刷新，并发请求合并为一次拉取
+// kid 为空且 JWKS 只有一个密钥时，返回该密钥
+func (s *jwksSource) key(ctx context.Context, kid string) (interface{}, error) {
+	s.mutex.RLock()
+	key, ok := s.lookup(kid)
+	sinceAttempt := time.Since(s.attemptedAt)
+	s.mutex.RUnlock()
+
+	if ok {
+		if s.refreshInterval > 0 && sinceAttempt > s.refreshInterval {
+			s.refreshInBackground()
+		}
+		return key, nil
+	}
+
+	if sinceAttempt > jwksMinRefreshInterval {
+		if err := s.refreshShared(ctx); err != nil {
+			return nil, err
+		}
+		s.mutex.RLock()
+		key, ok = s.lookup(kid)
+		s.mutex.RUnlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("signing key %q not found in jwks", kid)
+	}
+	return key, nil
+}
+
+// lookup 在当前密钥集中查找密钥，调用方需持有读锁
+func (s *jwksSource) lookup(kid string) (interface{}, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// refreshInBackground 在后台刷新 JWKS，已有刷新在进行时直接返回
+func (s *jwksSource) refreshInBackground() {
+	if !s.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer s.refreshing.Store(false)
+		_ = s.refreshShared(context.Background())
+	}()
+}
+
+// refreshShared 合并并发的刷新请求
+// 拉取不受单个请求取消的影响，由 HTTP 客户端的超时时间控制
+func (s *jwksSource) refreshShared(ctx context.Context) error {
+	_, err, _ := s.group.Do("jwks", func() (interface{}, error) {
+		return nil, s.refresh(context.WithoutCancel(ctx))
+	})
+	return err
+}
+
+// refresh 重新加载 JWKS
+func (s *jwksSource) refresh(ctx context.Context) error {
+	s.mutex.Lock()
+	s.attemptedAt = time.Now()
+	s.mutex.Unlock()
+
+	data, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.keys = keys
+	s.mutex.Unlock()
+	return nil
+}
+
+// read 从文件或 HTTP 端点读取 JWKS 原始内容
+func (s *jwksSource) read(ctx context.Context) ([]byte, error) {
+	if s.file != "" {
+		data, err := os.ReadFile(s.file)
+		if err != nil {
+			return nil, fmt.Errorf("read jwks file: %w", err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create jwks request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w: %w", ErrValidatorUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: %w: unexpected status %d", ErrValidatorUnavailable, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read jwks response: %w", err)
+	}
+	return data, nil
+}
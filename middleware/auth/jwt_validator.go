@@ -0,0 +1,249 @@
+// Package auth 提供认证中间件和工具函数
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTConfig 本地 JWT 验证配置
+type JWTConfig struct {
+	// 允许的签名算法，默认 ["HS256", "RS256", "ES256"]
+	Algorithms []string `json:"algorithms" yaml:"algorithms"`
+
+	// HMAC 密钥（HS256）
+	HMACSecret string `json:"hmac_secret" yaml:"hmac_secret"`
+
+	// JWKS 文件路径（RS256/ES256 公钥），与 JWKSURL 二选一
+	JWKSFile string `json:"jwks_file" yaml:"jwks_file"`
+
+	// JWKS HTTP 端点，如 "http://passport-service:8000/.well-known/jwks.json"
+	JWKSURL string `json:"jwks_url" yaml:"jwks_url"`
+
+	// JWKS 刷新间隔，默认 10 分钟；遇到未知 kid 时也会触发刷新
+	JWKSRefreshInterval time.Duration `json:"jwks_refresh_interval" yaml:"jwks_refresh_interval"`
+
+	// 期望的签发者（iss），为空时不校验
+	Issuer string `json:"issuer" yaml:"issuer"`
+
+	// 期望的受众（aud），为空时不校验
+	Audience string `json:"audience" yaml:"audience"`
+
+	// 校验 exp/nbf 时允许的时钟偏差
+	Leeway time.Duration `json:"leeway" yaml:"leeway"`
+
+	// 用户 ID 所在的声明，默认 "sub"
+	UserIDClaim string `json:"user_id_claim" yaml:"user_id_claim"`
+
+	// 角色所在的声明，默认 "role"
+	RoleClaim string `json:"role_claim" yaml:"role_claim"`
+
+	// 角色列表所在的声明，默认 "roles"
+	RolesClaim string `json:"roles_claim" yaml:"roles_claim"`
+
+	// 授权范围所在的声明，默认 "scope"（空格分隔的字符串或字符串数组）
+	ScopeClaim string `json:"scope_claim" yaml:"scope_claim"`
+
+	// 租户/应用 ID 所在的声明，默认 "tenant_id"
+	TenantIDClaim string `json:"tenant_id_claim" yaml:"tenant_id_claim"`
+
+	// 会话 ID 所在的声明，默认 "sid"
+	SessionIDClaim string `json:"session_id_claim" yaml:"session_id_claim"`
+}
+
+const (
+	defaultJWKSRefreshInterval = 10 * time.Minute
+	defaultUserIDClaim         = "sub"
+	defaultRoleClaim           = "role"
+	defaultRolesClaim          = "roles"
+	defaultScopeClaim          = "scope"
+	defaultTenantIDClaim       = "tenant_id"
+	defaultSessionIDClaim      = "sid"
+)
+
+// registeredClaims JWT 注册声明（RFC 7519），不会放入 UserClaims.Custom
+var registeredClaims = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+// defaultJWTAlgorithms 默认允许的签名算法
+var defaultJWTAlgorithms = []string{"HS256", "RS256", "ES256"}
+
+// JWTValidator 本地 JWT 验证器，TokenValidator 的本地实现
+// 在本地校验签名和 exp/nbf/aud/iss 声明，不需要调用 passport-service
+type JWTValidator struct {
+	config     JWTConfig
+	parser     *jwt.Parser
+	hmacSecret []byte
+	jwks       *jwksSource
+	log        *log.Helper
+}
+
+// NewJWTValidator 创建本地 JWT 验证器
+// 至少需要配置 HMACSecret、JWKSFile、JWKSURL 之一
+func NewJWTValidator(config *JWTConfig, logger log.Logger) (*JWTValidator, error) {
+	if config == nil {
+		return nil, errors.New("jwt config is required")
+	}
+
+	conf := *config
+	if len(conf.Algorithms) == 0 {
+		conf.Algorithms = defaultJWTAlgorithms
+	}
+	if conf.JWKSRefreshInterval == 0 {
+		conf.JWKSRefreshInterval = defaultJWKSRefreshInterval
+	}
+	if conf.UserIDClaim == "" {
+		conf.UserIDClaim = defaultUserIDClaim
+	}
+	if conf.RoleClaim == "" {
+		conf.RoleClaim = defaultRoleClaim
+	}
+	if conf.RolesClaim == "" {
+		conf.RolesClaim = defaultRolesClaim
+	}
+	if conf.ScopeClaim == "" {
+		conf.ScopeClaim = defaultScopeClaim
+	}
+	if conf.TenantIDClaim == "" {
+		conf.TenantIDClaim = defaultTenantIDClaim
+	}
+	if conf.SessionIDClaim == "" {
+		conf.SessionIDClaim = defaultSessionIDClaim
+	}
+	if conf.HMACSecret == "" && conf.JWKSFile == "" && conf.JWKSURL == "" {
+		return nil, errors.New("jwt config requires hmac_secret, jwks_file or jwks_url")
+	}
+
+	v := &JWTValidator{
+		config: conf,
+		log:    log.NewHelper(logger),
+	}
+	if conf.HMACSecret != "" {
+		v.hmacSecret = []byte(conf.HMACSecret)
+	}
+
+	if conf.JWKSFile != "" || conf.JWKSURL != "" {
+		jwks, err := newJWKSSource(context.Background(), conf.JWKSFile, conf.JWKSURL, conf.JWKSRefreshInterval)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwks: %w", err)
+		}
+		v.jwks = jwks
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(conf.Algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(conf.Leeway),
+	}
+	if conf.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(conf.Issuer))
+	}
+	if conf.Audience != "" {
+		opts = append(opts, jwt.WithAudience(conf.Audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+
+	return v, nil
+}
+
+// ValidateToken 验证 token 签名和声明，返回用户声明信息
+func (v *JWTValidator) ValidateToken(ctx context.Context, token string) (*UserClaims, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, v.keyFunc(ctx)); err != nil {
+		return nil, fmt.Errorf("validate token failed: %w", err)
+	}
+
+	userID := claimString(claims, v.config.UserIDClaim)
+	if userID == "" {
+		return nil, fmt.Errorf("validate token failed: missing %s claim", v.config.UserIDClaim)
+	}
+
+	userClaims := &UserClaims{
+		UserID:    userID,
+		Role:      claimString(claims, v.config.RoleClaim),
+		Roles:     claimStrings(claims, v.config.RolesClaim),
+		Scopes:    claimStrings(claims, v.config.ScopeClaim),
+		TenantID:  claimString(claims, v.config.TenantIDClaim),
+		SessionID: claimString(claims, v.config.SessionIDClaim),
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		userClaims.ExpiresAt = exp.Time
+	}
+
+	// 其余声明作为自定义声明
+	mapped := append([]string{
+		v.config.UserIDClaim, v.config.RoleClaim, v.config.RolesClaim,
+		v.config.ScopeClaim, v.config.TenantIDClaim, v.config.SessionIDClaim,
+	}, registeredClaims...)
+	for name, value := range claims {
+		if slices.Contains(mapped, name) {
+			continue
+		}
+		if userClaims.Custom == nil {
+			userClaims.Custom = make(map[string]interface{})
+		}
+		userClaims.Custom[name] = value
+	}
+
+	return userClaims, nil
+}
+
+// keyFunc 根据 token 的算法和 kid 选择验证密钥
+// HS* 优先使用 HMACSecret，RS*/ES* 使用 JWKS 中的公钥；
+// 密钥类型与算法不匹配时 jwt 库会拒绝验证，避免算法混淆攻击
+func (v *JWTValidator) keyFunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if strings.HasPrefix(token.Method.Alg(), "HS") && v.hmacSecret != nil {
+			return v.hmacSecret, nil
+		}
+
+		if v.jwks == nil {
+			return nil, fmt.Errorf("no key configured for algorithm %s", token.Method.Alg())
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, err := v.jwks.key(ctx, kid)
+		if err != nil {
+			v.log.Warnf("jwks key lookup failed: %v", err)
+			return nil, err
+		}
+		return key, nil
+	}
+}
+
+// claimStrings 读取字符串列表声明，支持字符串数组或空格分隔的字符串（如 OAuth2 scope）
+func claimStrings(claims jwt.MapClaims, name string) []string {
+	switch value := claims[name].(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// claimString 读取字符串声明，数字声明会被转换为字符串
+func claimString(claims jwt.MapClaims, name string) string {
+	switch value := claims[name].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":  "user-1",
+		"role": "admin",
+		"iss":  "passport",
+		"aud":  "go-pkg",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestJWTValidator_HS256(t *testing.T) {
+	validator, err := NewJWTValidator(&JWTConfig{HMACSecret: "secret", Issuer: "passport", Audience: "go-pkg"}, log.DefaultLogger)
+	require.NoError(t, err)
+
+	claims, err := validator.ValidateToken(context.Background(), signToken(t, jwt.SigningMethodHS256, []byte("secret"), "", validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+	assert.Equal(t, "admin", claims.Role)
+	assert.False(t, claims.ExpiresAt.IsZero())
+	assert.Nil(t, claims.Custom)
+
+	_, err = validator.ValidateToken(context.Background(), signToken(t, jwt.SigningMethodHS256, []byte("other"), "", validClaims()))
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	_, err = validator.ValidateToken(context.Background(), signToken(t, jwt.SigningMethodHS256, []byte("secret"), "", expired))
+	assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+
+	wrongAudience := validClaims()
+	wrongAudience["aud"] = "other-service"
+	_, err = validator.ValidateToken(context.Background(), signToken(t, jwt.SigningMethodHS256, []byte("secret"), "", wrongAudience))
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+
+	notYetValid := validClaims()
+	notYetValid["nbf"] = time.Now().Add(time.Hour).Unix()
+	_, err = validator.ValidateToken(context.Background(), signToken(t, jwt.SigningMethodHS256, []byte("secret"), "", notYetValid))
+	assert.ErrorIs(t, err, jwt.ErrTokenNotValidYet)
+}
+
+func TestJWTValidator_ClaimsMapping(t *testing.T) {
+	validator, err := NewJWTValidator(&JWTConfig{HMACSecret: "secret"}, log.DefaultLogger)
+	require.NoError(t, err)
+
+	claims := validClaims()
+	claims["roles"] = []interface{}{"editor", "viewer"}
+	claims["scope"] = "orders:read orders:write"
+	claims["tenant_id"] = "tenant-1"
+	claims["sid"] = "session-1"
+	claims["plan"] = "pro"
+
+	userClaims, err := validator.ValidateToken(context.Background(), signToken(t, jwt.SigningMethodHS256, []byte("secret"), "", claims))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor", "viewer"}, userClaims.Roles)
+	assert.Equal(t, []string{"orders:read", "orders:write"}, userClaims.Scopes)
+	assert.Equal(t, "tenant-1", userClaims.TenantID)
+	assert.Equal(t, "session-1", userClaims.SessionID)
+	assert.Equal(t, map[string]interface{}{"plan": "pro"}, userClaims.Custom)
+	assert.True(t, userClaims.HasRole("admin"))
+	assert.True(t, userClaims.HasRole("viewer"))
+	assert.True(t, userClaims.HasScope("orders:write"))
+	assert.False(t, userClaims.HasScope("orders:delete"))
+}
+
+func TestJWTValidator_RS256FromFile(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA", "kid": "rsa-1", "use": "sig",
+		"n": encodeBigInt(key.N), "e": encodeBigInt(big.NewInt(int64(key.E))),
+	}}})
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(file, jwks, 0o600))
+
+	validator, err := NewJWTValidator(&JWTConfig{JWKSFile: file}, log.DefaultLogger)
+	require.NoError(t, err)
+
+	claims, err := validator.ValidateToken(context.Background(), signToken(t, jwt.SigningMethodRS256, key, "rsa-1", validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+
+	// HS256 不允许使用 RSA 公钥作为 HMAC 密钥（算法混淆）
+	_, err = validator.ValidateToken(context.Background(), signToken(t, jwt.SigningMethodHS256, []byte("secret"), "rsa-1", validClaims()))
+	assert.Error(t, err)
+}
+
+func TestJWTValidator_ES256FromURL(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "EC", "kid": "ec-1", "crv": "P-256",
+			"x": encodeBigInt(key.X), "y": encodeBigInt(key.Y),
+		}}})
+	}))
+	defer server.Close()
+
+	validator, err := NewJWTValidator(&JWTConfig{JWKSURL: server.URL, UserIDClaim: "uid"}, log.DefaultLogger)
+	require.NoError(t, err)
+
+	claims := validClaims()
+	claims["uid"] = float64(10086)
+	userClaims, err := validator.ValidateToken(context.Background(), signToken(t, jwt.SigningMethodES256, key, "ec-1", claims))
+	require.NoError(t, err)
+	assert.Equal(t, "10086", userClaims.UserID)
+
+	_, err = validator.ValidateToken(context.Background(), signToken(t, jwt.SigningMethodES256, key, "unknown", claims))
+	assert.Error(t, err)
+}
+
+func TestJWKSSourceStaleKeysDuringOutage(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var hits atomic.Int32
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if down.Load() {
+			time.Sleep(50 * time.Millisecond)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "EC", "kid": "ec-1", "crv": "P-256",
+			"x": encodeBigInt(key.X), "y": encodeBigInt(key.Y),
+		}}})
+	}))
+	defer server.Close()
+
+	source, err := newJWKSSource(context.Background(), "", server.URL, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), hits.Load())
+
+	// JWKS 已过期且端点不可用：继续使用旧密钥，只在后台发起一次刷新
+	down.Store(true)
+	source.mutex.Lock()
+	source.attemptedAt = time.Now().Add(-2 * time.Minute)
+	source.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			got, err := source.key(context.Background(), "ec-1")
+			assert.NoError(t, err)
+			assert.NotNil(t, got)
+			assert.Less(t, time.Since(start), 40*time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	assert.Eventually(t, func() bool { return !source.refreshing.Load() }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), hits.Load())
+
+	// 刷新失败后在下一个间隔之前不再重试
+	_, err = source.key(context.Background(), "ec-1")
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(2), hits.Load())
+}
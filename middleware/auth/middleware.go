@@ -3,84 +3,90 @@ package auth
 
 import (
 	"context"
-	"strings"
 
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 // Middleware 认证中间件，验证 token 并提取用户信息
-// 如果 token 验证失败，不阻止请求，但不在 context 中设置用户信息
-// 这样可以让某些接口允许匿名访问
+// 如果 token 验证失败，默认不阻止请求，但不在 context 中设置用户信息，
+// 而是记录失败原因（GetAuthFailureFromContext），这样可以让某些接口允许匿名访问
+// 使用 WithRejectInvalidToken 时，携带了无效 token 的请求会直接被拒绝
+// validator: Token 验证器，如 PassportTokenValidator 或 JWTValidator
 // config: 认证配置，包含路由白名单
-func Middleware(validator *PassportTokenValidator, config *Config, logger log.Logger) middleware.Middleware {
+func Middleware(validator TokenValidator, config *Config, logger log.Logger, opts ...MiddlewareOption) middleware.Middleware {
+	options := newMiddlewareOptions(opts)
+	conf := config.withDefaults()
+	skip := config.newSkipper()
+
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
 			// 检查路径是否在白名单中
 			tr, ok := transport.FromServerContext(ctx)
-			if ok {
-				operation := tr.Operation()
-				if config != nil && config.ShouldSkipPath(operation) {
-					// 白名单路径，直接跳过认证
-					return handler(ctx, req)
-				}
+			if !ok {
+				return handler(ctx, req)
+			}
+			if skip.skip(tr) {
+				// 白名单路径，直接跳过认证
+				return handler(ctx, req)
 			}
 
-			// 从 transport 中获取 token
-			if ok {
-				authHeader := strings.TrimSpace(tr.RequestHeader().Get("Authorization"))
-				if authHeader == "" {
-					cookieHeader := strings.TrimSpace(tr.RequestHeader().Get("Cookie"))
-					if cookieHeader != "" {
-						parts := strings.Split(cookieHeader, ";")
-						for _, part := range parts {
-							part = strings.TrimSpace(part)
-							if strings.HasPrefix(part, "access_token=") {
-								tokenValue := strings.TrimPrefix(part, "access_token=")
-								authHeader = "Bearer " + tokenValue
-								break
-							}
-						}
-					}
-				}
+			// 从 transport 中获取凭证
+			creds, reason := extractCredentials(tr, conf)
+			if reason == FailureMissing {
+				return handler(ctx, req)
+			}
 
-				if authHeader != "" {
-					// 解析 Bearer token
-					parts := strings.Split(authHeader, " ")
-					if len(parts) == 2 && parts[0] == "Bearer" {
-						token := parts[1]
-
-						// 验证 token
-						claims, err := validator.ValidateToken(ctx, token)
-						if err != nil {
-							log.NewHelper(logger).Warnf("Token validation failed: %v", err)
-							// 如果 token 验证失败，不阻止请求，但不在 context 中设置用户信息
-							// 这样可以让某些接口允许匿名访问
-						} else {
-							// 将用户信息存储到 context 中
-							ctx = WithUserClaims(ctx, claims)
-						}
-					}
+			var err error
+			if reason == "" {
+				// 验证凭证
+				var claims *UserClaims
+				claims, err = options.validate(ctx, validator, creds)
+				if err == nil {
+					// 将用户信息存储到 context 中
+					return handler(WithUserClaims(ctx, claims), req)
 				}
+				reason = ClassifyError(err)
 			}
 
-			return handler(ctx, req)
+			log.NewHelper(logger).Warnf("Token validation failed: reason=%s err=%v", reason, err)
+			options.audit(ctx, reason, err)
+			if options.rejectInvalid {
+				return nil, newAuthError(ctx, options.errorManager, reason.ErrorCode())
+			}
+
+			// 不阻止请求，但记录失败原因，RequireAuth 据此返回更准确的错误码
+			return handler(WithAuthFailure(ctx, reason), req)
+		}
+	}
+}
+
+// validate 按认证方案验证凭证
+func (o *middlewareOptions) validate(ctx context.Context, validator TokenValidator, creds *credentials) (*UserClaims, error) {
+	if creds.scheme == SchemeBasic {
+		if o.basicValidator == nil {
+			return nil, ErrUnsupportedScheme
 		}
+		return o.basicValidator.ValidateBasic(ctx, creds.username, creds.password)
 	}
+	return validator.ValidateToken(ctx, creds.token)
 }
 
 // RequireAuth 要求认证的中间件，如果未认证则返回错误
-func RequireAuth(validator *PassportTokenValidator, config *Config, logger log.Logger) middleware.Middleware {
+// 错误码根据 Middleware 记录的失败原因确定：token 过期返回 ErrCodeTokenExpired，
+// token 无效返回 ErrCodeTokenInvalid，未携带 token 返回 ErrCodeUnauthorized
+func RequireAuth(validator TokenValidator, config *Config, logger log.Logger, opts ...MiddlewareOption) middleware.Middleware {
+	options := newMiddlewareOptions(opts)
+	skip := config.newSkipper()
+
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
 			// 检查路径是否在白名单中
 			tr, ok := transport.FromServerContext(ctx)
 			if ok {
-				operation := tr.Operation()
-				if config != nil && config.ShouldSkipPath(operation) {
+				if skip.skip(tr) {
 					// 白名单路径，直接跳过认证
 					return handler(ctx, req)
 				}
@@ -88,7 +94,7 @@ func RequireAuth(validator *PassportTokenValidator, config *Config, logger log.L
 
 			claims, ok := GetUserClaimsFromContext(ctx)
 			if !ok || claims.UserID == "" {
-				return nil, status.Error(codes.Unauthenticated, "authentication required")
+				return nil, options.unauthenticated(ctx)
 			}
 
 			return handler(ctx, req)
@@ -96,27 +102,31 @@ func RequireAuth(validator *PassportTokenValidator, config *Config, logger log.L
 	}
 }
 
-// RequireRole 要求特定角色的中间件
-func RequireRole(requiredRole string, validator *PassportTokenValidator, config *Config, logger log.Logger) middleware.Middleware {
+// RequireRole 要求特定角色的中间件（匹配 UserClaims.Role 或 UserClaims.Roles）
+// 未认证时的错误与 RequireAuth 一致，角色不匹配时返回 ErrCodeForbidden
+func RequireRole(requiredRole string, validator TokenValidator, config *Config, logger log.Logger, opts ...MiddlewareOption) middleware.Middleware {
+	options := newMiddlewareOptions(opts)
+	skip := config.newSkipper()
+
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
 			// 检查路径是否在白名单中
 			tr, ok := transport.FromServerContext(ctx)
 			if ok {
-				operation := tr.Operation()
-				if config != nil && config.ShouldSkipPath(operation) {
+				if skip.skip(tr) {
 					// 白名单路径，直接跳过认证
 					return handler(ctx, req)
 				}
 			}
 
 			claims, ok := GetUserClaimsFromContext(ctx)
-			if !ok {
-				return nil, status.Error(codes.Unauthenticated, "authentication required")
+			if !ok || claims.UserID == "" {
+				return nil, options.unauthenticated(ctx)
 			}
 
-			if claims.Role != requiredRole {
-				return nil, status.Error(codes.PermissionDenied, "insufficient permissions")
+			if !claims.HasRole(requiredRole) {
+				options.audit(ctx, FailureForbidden, nil)
+				return nil, newAuthError(ctx, options.errorManager, pkgErrors.ErrCodeForbidden)
 			}
 
 			return handler(ctx, req)
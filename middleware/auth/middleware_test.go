@@ -0,0 +1,157 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/auth"
+	"github.com/gaoyong06/go-pkg/middleware/auth/authtest"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const operation = "/api.order.v1.Order/GetOrder"
+
+// echoUser 返回 context 中的用户 ID
+func echoUser(ctx context.Context, req interface{}) (interface{}, error) {
+	return auth.GetUserIDFromContext(ctx), nil
+}
+
+func errorCode(t *testing.T, err error) int32 {
+	t.Helper()
+	require.Error(t, err)
+	return kratosErrors.FromError(err).Code
+}
+
+func TestMiddleware(t *testing.T) {
+	validator := authtest.NewFakeValidator().
+		AddToken("good", &auth.UserClaims{UserID: "u1", Role: "admin"}).
+		AddError("expired", jwt.ErrTokenExpired)
+	config := &auth.Config{SkipPaths: []string{"/api.order.v1.Order/Health"}}
+	handler := auth.Middleware(validator, config, log.DefaultLogger)(echoUser)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		want    string
+		failure auth.FailureReason
+	}{
+		{"有效 token", authtest.BearerContext(context.Background(), operation, "good"), "u1", ""},
+		{"scheme 不区分大小写", authtest.ServerContext(context.Background(), operation, map[string]string{"Authorization": "bearer good"}), "u1", ""},
+		{"Cookie 中的 token", authtest.ServerContext(context.Background(), operation, map[string]string{"Cookie": "access_token=good"}), "u1", ""},
+		{"匿名请求", authtest.ServerContext(context.Background(), operation, nil), "", ""},
+		{"过期 token 降级为匿名", authtest.BearerContext(context.Background(), operation, "expired"), "", auth.FailureExpired},
+		{"未知 token 降级为匿名", authtest.BearerContext(context.Background(), operation, "unknown"), "", auth.FailureInvalid},
+		{"白名单不验证", authtest.BearerContext(context.Background(), "/api.order.v1.Order/Health", "good"), "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failure auth.FailureReason
+			h := auth.Middleware(validator, config, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+				failure = auth.GetAuthFailureFromContext(ctx)
+				return echoUser(ctx, req)
+			})
+			got, err := h(tt.ctx, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.failure, failure)
+		})
+	}
+
+	// 白名单请求不调用验证器
+	calls := len(validator.Calls())
+	_, err := handler(authtest.BearerContext(context.Background(), "/api.order.v1.Order/Health", "good"), nil)
+	require.NoError(t, err)
+	assert.Len(t, validator.Calls(), calls)
+}
+
+func TestRequireAuth(t *testing.T) {
+	validator := authtest.NewFakeValidator().
+		AddToken("good", &auth.UserClaims{UserID: "u1"}).
+		AddError("expired", jwt.ErrTokenExpired)
+	handler := middleware.Chain(
+		auth.Middleware(validator, nil, log.DefaultLogger),
+		auth.RequireAuth(validator, &auth.Config{SkipPaths: []string{"/api.order.v1.Order/Public*"}}, log.DefaultLogger),
+	)(echoUser)
+
+	got, err := handler(authtest.BearerContext(context.Background(), operation, "good"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "u1", got)
+
+	_, err = handler(authtest.ServerContext(context.Background(), operation, nil), nil)
+	assert.Equal(t, int32(pkgErrors.ErrCodeUnauthorized), errorCode(t, err))
+
+	_, err = handler(authtest.BearerContext(context.Background(), operation, "expired"), nil)
+	assert.Equal(t, int32(pkgErrors.ErrCodeTokenExpired), errorCode(t, err))
+
+	_, err = handler(authtest.BearerContext(context.Background(), operation, "unknown"), nil)
+	assert.Equal(t, int32(pkgErrors.ErrCodeTokenInvalid), errorCode(t, err))
+
+	_, err = handler(authtest.ServerContext(context.Background(), "/api.order.v1.Order/PublicList", nil), nil)
+	assert.NoError(t, err)
+}
+
+func TestRequireRole(t *testing.T) {
+	validator := authtest.NewFakeValidator()
+	handler := auth.RequireRole("admin", validator, nil, log.DefaultLogger)(echoUser)
+
+	got, err := handler(authtest.WithClaims(authtest.ServerContext(context.Background(), operation, nil), "u1", "admin"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "u1", got)
+
+	// 多角色中包含 admin
+	_, err = handler(authtest.WithClaims(authtest.ServerContext(context.Background(), operation, nil), "u2", "editor", "admin"), nil)
+	assert.NoError(t, err)
+
+	_, err = handler(authtest.WithClaims(authtest.ServerContext(context.Background(), operation, nil), "u3", "editor"), nil)
+	assert.Equal(t, int32(pkgErrors.ErrCodeForbidden), errorCode(t, err))
+
+	_, err = handler(authtest.ServerContext(context.Background(), operation, nil), nil)
+	assert.Equal(t, int32(pkgErrors.ErrCodeUnauthorized), errorCode(t, err))
+}
+
+func TestPassportStub(t *testing.T) {
+	stub := authtest.NewPassportStub(t, map[string]*auth.UserClaims{
+		"good": {UserID: "u1", Role: "admin"},
+	})
+	validator := stub.Validator(log.DefaultLogger)
+
+	claims, err := validator.ValidateToken(context.Background(), "good")
+	require.NoError(t, err)
+	assert.Equal(t, "u1", claims.UserID)
+	assert.Equal(t, "admin", claims.Role)
+
+	_, err = validator.ValidateToken(context.Background(), "bad")
+	assert.Equal(t, auth.FailureInvalid, auth.ClassifyError(err))
+
+	// 过期原因经过 gRPC 传输后仍能识别
+	stub.AddToken("stale", &auth.UserClaims{UserID: "u2"})
+	stub.ExpireToken("stale")
+	_, err = validator.ValidateToken(context.Background(), "stale")
+	assert.Equal(t, auth.FailureExpired, auth.ClassifyError(err))
+	handler := middleware.Chain(
+		auth.Middleware(validator, nil, log.DefaultLogger),
+		auth.RequireAuth(validator, nil, log.DefaultLogger),
+	)(echoUser)
+	_, err = handler(authtest.BearerContext(context.Background(), operation, "stale"), nil)
+	assert.Equal(t, int32(pkgErrors.ErrCodeTokenExpired), errorCode(t, err))
+
+	stub.SetUnavailable(true)
+	_, err = validator.ValidateToken(context.Background(), "good")
+	assert.Equal(t, auth.FailureUnavailable, auth.ClassifyError(err))
+
+	// 通过中间件端到端验证
+	stub.SetUnavailable(false)
+	handler = middleware.Chain(
+		auth.Middleware(validator, nil, log.DefaultLogger),
+		auth.RequireRole("admin", validator, nil, log.DefaultLogger),
+	)(echoUser)
+	got, err := handler(authtest.BearerContext(context.Background(), operation, "good"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "u1", got)
+}
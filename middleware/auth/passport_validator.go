@@ -6,19 +6,19 @@ import (
 	"fmt"
 	"time"
 
-	passportv1 "passport-service/api/passport/v1"
-
+	"github.com/gaoyong06/go-pkg/middleware/auth/passportpb"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware/recovery"
 	"github.com/go-kratos/kratos/v2/transport/grpc"
+	grpcgo "google.golang.org/grpc"
 )
 
-// PassportTokenValidator PassportService Token 验证器
+// PassportTokenValidator PassportService Token 验证器，TokenValidator 的 gRPC 实现
 // 所有服务都调用同一个 passport-service，所以直接在公共库中实现
 // 服务间调用使用 gRPC（性能更好、类型安全）
-// 注意：不需要接口抽象，因为所有服务都统一使用 passport-service
+// 每次验证都会发起一次 gRPC 调用；如需避免网络开销，可使用 JWTValidator 本地验证
 type PassportTokenValidator struct {
-	client passportv1.PassportClient
+	client passportpb.PassportClient
 	log    *log.Helper
 }
 
@@ -40,7 +40,7 @@ func NewPassportTokenValidator(grpcAddr string, timeout time.Duration, logger lo
 	}
 
 	// 创建 gRPC 客户端
-	client := passportv1.NewPassportClient(conn)
+	client := passportpb.NewPassportClient(conn)
 
 	return &PassportTokenValidator{
 		client: client,
@@ -48,6 +48,15 @@ func NewPassportTokenValidator(grpcAddr string, timeout time.Duration, logger lo
 	}, nil
 }
 
+// NewPassportTokenValidatorWithConn 使用已建立的 gRPC 连接创建 PassportTokenValidator
+// 适用于复用连接、自定义拨号参数或测试中使用 bufconn 的场景
+func NewPassportTokenValidatorWithConn(conn grpcgo.ClientConnInterface, logger log.Logger) *PassportTokenValidator {
+	return &PassportTokenValidator{
+		client: passportpb.NewPassportClient(conn),
+		log:    log.NewHelper(logger),
+	}
+}
+
 // NewPassportTokenValidatorWithDefaults 使用默认超时时间创建
 // 默认超时时间为 10 秒
 func NewPassportTokenValidatorWithDefaults(grpcAddr string, logger log.Logger) (*PassportTokenValidator, error) {
@@ -56,7 +65,7 @@ func NewPassportTokenValidatorWithDefaults(grpcAddr string, logger log.Logger) (
 
 // ValidateToken 验证 token
 func (v *PassportTokenValidator) ValidateToken(ctx context.Context, token string) (*UserClaims, error) {
-	req := &passportv1.ValidateTokenRequest{
+	req := &passportpb.ValidateTokenRequest{
 		Token: token,
 	}
 
@@ -0,0 +1,88 @@
+// Package passportpb passport-service ValidateToken 接口的最小 gRPC 契约
+//
+// 消息由 go_pkg_passport.proto 生成（在本目录执行 go generate，需要 buf 和 protoc-gen-go）。
+// 客户端和服务描述手写而不是由 protoc-gen-go-grpc 生成：消息使用独立的 proto 包名以避免与
+// passport-service 生成的代码冲突，但 gRPC 方法名必须与 passport-service 保持一致。
+package passportpb
+
+//go:generate buf generate
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	// ServiceName passport-service 的 gRPC 服务名
+	ServiceName = "passport.v1.Passport"
+	// ValidateTokenFullMethodName ValidateToken 的完整 gRPC 方法名
+	ValidateTokenFullMethodName = "/passport.v1.Passport/ValidateToken"
+)
+
+// PassportClient passport-service 客户端（仅包含 ValidateToken）
+type PassportClient interface {
+	ValidateToken(ctx context.Context, in *ValidateTokenRequest, opts ...grpc.CallOption) (*ValidateTokenResponse, error)
+}
+
+// passportClient PassportClient 的 gRPC 实现
+type passportClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewPassportClient 创建 passport-service 客户端
+func NewPassportClient(cc grpc.ClientConnInterface) PassportClient {
+	return &passportClient{cc: cc}
+}
+
+// ValidateToken 验证 token
+func (c *passportClient) ValidateToken(ctx context.Context, in *ValidateTokenRequest, opts ...grpc.CallOption) (*ValidateTokenResponse, error) {
+	out := new(ValidateTokenResponse)
+	if err := c.cc.Invoke(ctx, ValidateTokenFullMethodName, in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PassportServer passport-service 服务端接口（仅包含 ValidateToken），用于测试替身
+type PassportServer interface {
+	ValidateToken(context.Context, *ValidateTokenRequest) (*ValidateTokenResponse, error)
+}
+
+// RegisterPassportServer 注册 passport-service 服务端实现
+func RegisterPassportServer(s grpc.ServiceRegistrar, srv PassportServer) {
+	s.RegisterService(&passportServiceDesc, srv)
+}
+
+// validateTokenHandler ValidateToken 的服务端处理函数
+func validateTokenHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PassportServer).ValidateToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ValidateTokenFullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PassportServer).ValidateToken(ctx, req.(*ValidateTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// passportServiceDesc passport-service 的 gRPC 服务描述
+var passportServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*PassportServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ValidateToken",
+			Handler:    validateTokenHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "go_pkg_passport.proto",
+}
@@ -0,0 +1,159 @@
+// Package auth 提供认证中间件和工具函数
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const (
+	// ClaimsHeader 转发的用户声明（base64url 编码的 JSON）
+	ClaimsHeader = "x-auth-claims"
+	// ClaimsSignatureHeader 转发声明的 HMAC-SHA256 签名（hex 编码）
+	ClaimsSignatureHeader = "x-auth-claims-signature"
+
+	// defaultPropagationMaxAge 转发声明的默认有效期
+	defaultPropagationMaxAge = 5 * time.Minute
+)
+
+// PropagationConfig 用户声明转发配置
+// 调用方和被调用方需要使用相同的 Secret
+type PropagationConfig struct {
+	// HMAC 签名密钥
+	Secret string `json:"secret" yaml:"secret"`
+
+	// 转发声明的有效期，默认 5 分钟，用于限制重放
+	MaxAge time.Duration `json:"max_age" yaml:"max_age"`
+}
+
+// propagatedClaims 转发载荷
+type propagatedClaims struct {
+	Claims   *UserClaims `json:"claims"`
+	IssuedAt int64       `json:"iat"`
+}
+
+// ForwardClaims 客户端中间件，将当前已认证的用户声明签名后写入下游请求的 metadata/header
+// 适用于 Kratos gRPC 和 HTTP 客户端；context 中没有用户声明时不做处理
+func ForwardClaims(config *PropagationConfig) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			claims, ok := GetUserClaimsFromContext(ctx)
+			if !ok || claims == nil {
+				return handler(ctx, req)
+			}
+
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+
+			payload, signature, err := signClaims(config, claims, timeNow())
+			if err != nil {
+				return nil, err
+			}
+			tr.RequestHeader().Set(ClaimsHeader, payload)
+			tr.RequestHeader().Set(ClaimsSignatureHeader, signature)
+
+			return handler(ctx, req)
+		}
+	}
+}
+
+// TrustForwardedClaims 服务端中间件，校验上游通过 ForwardClaims 转发的用户声明
+// 签名有效且未过期时将声明存入 context，被调用方无需再次验证 token；
+// 校验失败时记录告警并忽略转发的声明（不阻止请求，与 Middleware 行为一致）
+func TrustForwardedClaims(config *PropagationConfig, logger log.Logger) middleware.Middleware {
+	logHelper := log.NewHelper(logger)
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+
+			payload := tr.RequestHeader().Get(ClaimsHeader)
+			if payload == "" {
+				return handler(ctx, req)
+			}
+
+			claims, err := verifyClaims(config, payload, tr.RequestHeader().Get(ClaimsSignatureHeader), timeNow())
+			if err != nil {
+				logHelper.Warnf("Forwarded claims rejected: %v", err)
+				return handler(ctx, req)
+			}
+
+			return handler(WithUserClaims(ctx, claims), req)
+		}
+	}
+}
+
+// signClaims 序列化并签名用户声明，返回 (payload, signature)
+func signClaims(config *PropagationConfig, claims *UserClaims, now time.Time) (string, string, error) {
+	if config == nil || config.Secret == "" {
+		return "", "", errors.New("claims propagation secret is required")
+	}
+
+	data, err := json.Marshal(&propagatedClaims{Claims: claims, IssuedAt: now.Unix()})
+	if err != nil {
+		return "", "", fmt.Errorf("encode forwarded claims: %w", err)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload, claimsSignature(config.Secret, payload), nil
+}
+
+// verifyClaims 校验签名和有效期，返回用户声明
+func verifyClaims(config *PropagationConfig, payload, signature string, now time.Time) (*UserClaims, error) {
+	if config == nil || config.Secret == "" {
+		return nil, errors.New("claims propagation secret is required")
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(claimsSignature(config.Secret, payload))) {
+		return nil, errors.New("invalid signature")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+
+	var forwarded propagatedClaims
+	if err := json.Unmarshal(data, &forwarded); err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+	if forwarded.Claims == nil || forwarded.Claims.UserID == "" {
+		return nil, errors.New("missing user id")
+	}
+
+	maxAge := config.MaxAge
+	if maxAge <= 0 {
+		maxAge = defaultPropagationMaxAge
+	}
+	if age := now.Sub(time.Unix(forwarded.IssuedAt, 0)); age > maxAge || age < -maxAge {
+		return nil, fmt.Errorf("forwarded claims expired (age %s)", age)
+	}
+	if !forwarded.Claims.ExpiresAt.IsZero() && now.After(forwarded.Claims.ExpiresAt) {
+		return nil, errors.New("token expired")
+	}
+
+	return forwarded.Claims, nil
+}
+
+// claimsSignature 计算 HMAC-SHA256 签名
+func claimsSignature(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
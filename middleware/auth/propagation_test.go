@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type headerCarrier map[string]string
+
+func (c headerCarrier) Get(key string) string      { return c[key] }
+func (c headerCarrier) Set(key, value string)      { c[key] = value }
+func (c headerCarrier) Add(key, value string)      { c[key] = value }
+func (c headerCarrier) Keys() []string             { return nil }
+func (c headerCarrier) Values(key string) []string { return []string{c[key]} }
+
+type testTransport struct {
+	header    headerCarrier
+	operation string
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *testTransport) Endpoint() string                { return "" }
+func (tr *testTransport) Operation() string               { return tr.operation }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.header }
+func (tr *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func TestClaimsPropagation(t *testing.T) {
+	config := &PropagationConfig{Secret: "shared-secret"}
+	claims := &UserClaims{UserID: "user-1", Roles: []string{"editor"}, TenantID: "tenant-1", ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Second)}
+
+	// 客户端：写入签名后的声明
+	header := headerCarrier{}
+	clientCtx := transport.NewClientContext(WithUserClaims(context.Background(), claims), &testTransport{header: header})
+	_, err := ForwardClaims(config)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})(clientCtx, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, header.Get(ClaimsHeader))
+	require.NotEmpty(t, header.Get(ClaimsSignatureHeader))
+
+	call := func(config *PropagationConfig, header headerCarrier) (*UserClaims, bool) {
+		var got *UserClaims
+		var ok bool
+		serverCtx := transport.NewServerContext(context.Background(), &testTransport{header: header})
+		_, err := TrustForwardedClaims(config, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+			got, ok = GetUserClaimsFromContext(ctx)
+			return nil, nil
+		})(serverCtx, nil)
+		require.NoError(t, err)
+		return got, ok
+	}
+
+	// 服务端：签名有效时信任声明
+	got, ok := call(config, header)
+	require.True(t, ok)
+	assert.Equal(t, "user-1", got.UserID)
+	assert.Equal(t, []string{"editor"}, got.Roles)
+	assert.Equal(t, "tenant-1", got.TenantID)
+	assert.True(t, claims.ExpiresAt.Equal(got.ExpiresAt))
+
+	// 密钥不一致
+	_, ok = call(&PropagationConfig{Secret: "other"}, header)
+	assert.False(t, ok)
+
+	// 篡改载荷
+	tampered := headerCarrier{ClaimsHeader: header.Get(ClaimsHeader) + "x", ClaimsSignatureHeader: header.Get(ClaimsSignatureHeader)}
+	_, ok = call(config, tampered)
+	assert.False(t, ok)
+
+	// 超过有效期
+	timeNow = func() time.Time { return time.Now().Add(10 * time.Minute) }
+	defer func() { timeNow = time.Now }()
+	_, ok = call(config, header)
+	assert.False(t, ok)
+}
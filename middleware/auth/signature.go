@@ -0,0 +1,407 @@
+// Package auth 提供认证中间件和工具函数
+package auth
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/i18n"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// SignatureKeyIDHeader 签名密钥 ID（标识调用方）
+	SignatureKeyIDHeader = "X-Signature-Key-Id"
+	// SignatureTimestampHeader 签名时间戳（Unix 秒）
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+	// SignatureNonceHeader 一次性随机数，用于防重放
+	SignatureNonceHeader = "X-Signature-Nonce"
+	// SignatureHeader HMAC-SHA256 签名（hex 编码）
+	SignatureHeader = "X-Signature"
+
+	defaultMaxClockSkew     = 5 * time.Minute
+	defaultNonceKeyPrefix   = "auth:nonce:"
+	maxSignatureBodyBytes   = 10 << 20
+	signatureNonceByteCount = 16
+)
+
+// ErrSignatureBodyTooLarge 请求 body 超过签名支持的上限（10 MB）
+var ErrSignatureBodyTooLarge = errors.New("request body too large to sign")
+
+// SignatureConfig 请求签名验证配置
+type SignatureConfig struct {
+	// 密钥 ID -> 签名密钥，每个调用方（合作伙伴/服务）一个
+	Secrets map[string]string `json:"secrets" yaml:"secrets"`
+
+	// 允许的时钟偏差，默认 5 分钟；nonce 的保留时间为该值的 2 倍
+	MaxClockSkew time.Duration `json:"max_clock_skew" yaml:"max_clock_skew"`
+}
+
+// NonceStore nonce 存储，用于拒绝重放的请求
+type NonceStore interface {
+	// Use 标记 nonce 已使用；nonce 首次出现时返回 true，已使用过返回 false
+	Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// RedisNonceStore 基于 Redis SETNX 的 nonce 存储，多实例共享
+type RedisNonceStore struct {
+	rdb    *redis.Client
+	prefix string
+}
+
+// NewRedisNonceStore 创建 Redis nonce 存储
+// prefix: 键前缀，为空时使用 "auth:nonce:"
+func NewRedisNonceStore(rdb *redis.Client, prefix string) *RedisNonceStore {
+	if prefix == "" {
+		prefix = defaultNonceKeyPrefix
+	}
+	return &RedisNonceStore{rdb: rdb, prefix: prefix}
+}
+
+// Use 标记 nonce 已使用
+func (s *RedisNonceStore) Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.rdb.SetNX(ctx, s.prefix+nonce, 1, ttl).Result()
+}
+
+// MemoryNonceStore 内存 nonce 存储，适用于单实例和测试
+// nonce 按过期时间保存在最小堆中，Use 只清理堆顶已过期的 nonce，不需要遍历全部 nonce
+type MemoryNonceStore struct {
+	mutex   sync.Mutex
+	nonces  map[string]time.Time // nonce -> 过期时间
+	expires nonceHeap
+}
+
+// NewMemoryNonceStore 创建内存 nonce 存储
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+// Use 标记 nonce 已使用，同时清理已过期的 nonce
+func (s *MemoryNonceStore) Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := timeNow()
+	for len(s.expires) > 0 && !now.Before(s.expires[0].expiresAt) {
+		expired := heap.Pop(&s.expires).(nonceEntry)
+		if expiresAt, ok := s.nonces[expired.nonce]; ok && !now.Before(expiresAt) {
+			delete(s.nonces, expired.nonce)
+		}
+	}
+
+	if expiresAt, ok := s.nonces[nonce]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+	expiresAt := now.Add(ttl)
+	s.nonces[nonce] = expiresAt
+	heap.Push(&s.expires, nonceEntry{nonce: nonce, expiresAt: expiresAt})
+	return true, nil
+}
+
+// nonceEntry nonce 及其过期时间
+type nonceEntry struct {
+	nonce     string
+	expiresAt time.Time
+}
+
+// nonceHeap 按过期时间排序的最小堆，实现 container/heap.Interface
+type nonceHeap []nonceEntry
+
+func (h nonceHeap) Len() int            { return len(h) }
+func (h nonceHeap) Less(i, j int) bool  { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h nonceHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nonceHeap) Push(x interface{}) { *h = append(*h, x.(nonceEntry)) }
+func (h *nonceHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// signatureKeyIDKey 是 context 中存储签名密钥 ID 的键
+type signatureKeyIDKey struct{}
+
+// SignatureKeyIDKey 导出签名密钥 ID 键，供外部使用
+var SignatureKeyIDKey = signatureKeyIDKey{}
+
+// GetSignatureKeyIDFromContext 从 context 中获取已验证请求的签名密钥 ID
+func GetSignatureKeyIDFromContext(ctx context.Context) string {
+	keyID, _ := ctx.Value(SignatureKeyIDKey).(string)
+	return keyID
+}
+
+// VerifySignature 请求签名验证中间件，用于合作伙伴回调等服务间调用
+// 签名内容：method、path（含 query）、时间戳、nonce 和 body 的 SHA-256 摘要，见 signaturePayload；
+// 时间戳超出允许偏差、nonce 重复使用或签名不匹配时拒绝请求
+// HTTP 请求对原始 body 签名；gRPC 请求对 proto 消息的确定性序列化结果签名（调用方使用 SignatureClient）
+// 验证通过后密钥 ID 存入 context，可通过 GetSignatureKeyIDFromContext 获取
+// 验证失败返回本地化的 ErrCodeUnauthorized（可通过 WithErrorMessages 使用服务的文案），body 超过上限返回 413
+func VerifySignature(config *SignatureConfig, nonces NonceStore, logger log.Logger, opts ...MiddlewareOption) middleware.Middleware {
+	options := newMiddlewareOptions(opts)
+	logHelper := log.NewHelper(logger)
+	maxSkew := defaultMaxClockSkew
+	if config != nil && config.MaxClockSkew > 0 {
+		maxSkew = config.MaxClockSkew
+	}
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+
+			keyID, err := verifyRequestSignature(ctx, tr, req, config, nonces, maxSkew)
+			if errors.Is(err, ErrSignatureBodyTooLarge) {
+				logHelper.Warnf("Request signature rejected: operation=%s err=%v", tr.Operation(), err)
+				return nil, pkgErrors.NewBizErrorWithFallback(ctx, nil, authMessages, i18n.Language(ctx), http.StatusRequestEntityTooLarge)
+			}
+			if err != nil {
+				logHelper.Warnf("Request signature rejected: operation=%s err=%v", tr.Operation(), err)
+				options.audit(ctx, FailureInvalid, err)
+				return nil, newAuthError(ctx, options.errorManager, pkgErrors.ErrCodeUnauthorized)
+			}
+
+			return handler(context.WithValue(ctx, SignatureKeyIDKey, keyID), req)
+		}
+	}
+}
+
+// verifyRequestSignature 校验请求签名，返回密钥 ID
+func verifyRequestSignature(ctx context.Context, tr transport.Transporter, req interface{}, config *SignatureConfig, nonces NonceStore, maxSkew time.Duration) (string, error) {
+	header := tr.RequestHeader()
+	keyID := header.Get(SignatureKeyIDHeader)
+	timestamp := header.Get(SignatureTimestampHeader)
+	nonce := header.Get(SignatureNonceHeader)
+	signature := header.Get(SignatureHeader)
+	if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+		return "", errors.New("missing signature headers")
+	}
+
+	var secret string
+	if config != nil {
+		secret = config.Secrets[keyID]
+	}
+	if secret == "" {
+		return "", fmt.Errorf("unknown key id %q", keyID)
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	if skew := timeNow().Sub(time.Unix(ts, 0)); skew > maxSkew || skew < -maxSkew {
+		return "", fmt.Errorf("timestamp outside allowed skew (%s)", skew)
+	}
+
+	method, path, body, err := signedRequestParts(tr, req)
+	if err != nil {
+		return "", err
+	}
+	expected := computeSignature(secret, signaturePayload(method, path, timestamp, nonce, body))
+	if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected)) {
+		return "", errors.New("signature mismatch")
+	}
+
+	// 签名通过后再记录 nonce，避免伪造请求占用合法 nonce
+	if nonces != nil {
+		fresh, err := nonces.Use(ctx, keyID+":"+nonce, 2*maxSkew)
+		if err != nil {
+			return "", fmt.Errorf("nonce store unavailable: %w", err)
+		}
+		if !fresh {
+			return "", fmt.Errorf("nonce %q already used", nonce)
+		}
+	}
+	return keyID, nil
+}
+
+// signedRequestParts 获取参与签名的 method、path 和 body
+func signedRequestParts(tr transport.Transporter, req interface{}) (string, string, []byte, error) {
+	if httpTr, ok := tr.(*kratoshttp.Transport); ok {
+		r := httpTr.Request()
+		body, err := readAndResetBody(r)
+		if err != nil {
+			return "", "", nil, err
+		}
+		return r.Method, r.URL.RequestURI(), body, nil
+	}
+
+	body, err := marshalSignedMessage(req)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return http.MethodPost, tr.Operation(), body, nil
+}
+
+// readAndResetBody 读取 HTTP 请求 body 并重置，供后续处理继续读取
+// body 超过 10 MB 时返回 ErrSignatureBodyTooLarge
+func readAndResetBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	// 多读 1 字节判断是否超出上限，不能只对截断后的前缀签名/验签
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignatureBodyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+	if len(body) > maxSignatureBodyBytes {
+		return nil, ErrSignatureBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// marshalSignedMessage gRPC 请求的签名内容：proto 消息的确定性序列化
+func marshalSignedMessage(req interface{}) ([]byte, error) {
+	if req == nil {
+		return nil, nil
+	}
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("unsupported request type %T", req)
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+}
+
+// signaturePayload 构造待签名字符串：
+// METHOD\nPATH\nTIMESTAMP\nNONCE\nhex(sha256(body))
+func signaturePayload(method, path, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+}
+
+// computeSignature 计算 HMAC-SHA256 签名（hex 编码）
+func computeSignature(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Signer 请求签名器，用于调用需要签名验证的接口
+type Signer struct {
+	KeyID  string
+	Secret string
+}
+
+// NewSigner 创建请求签名器
+func NewSigner(keyID, secret string) *Signer {
+	return &Signer{KeyID: keyID, Secret: secret}
+}
+
+// Headers 计算签名并返回需要附加的请求头
+func (s *Signer) Headers(method, path string, body []byte) (map[string]string, error) {
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+	timestamp := strconv.FormatInt(timeNow().Unix(), 10)
+
+	return map[string]string{
+		SignatureKeyIDHeader:     s.KeyID,
+		SignatureTimestampHeader: timestamp,
+		SignatureNonceHeader:     nonce,
+		SignatureHeader:          computeSignature(s.Secret, signaturePayload(method, path, timestamp, nonce, body)),
+	}, nil
+}
+
+// SignHTTPRequest 为 HTTP 请求签名，会读取并重置 body
+func (s *Signer) SignHTTPRequest(r *http.Request) error {
+	body, err := readAndResetBody(r)
+	if err != nil {
+		return err
+	}
+	headers, err := s.Headers(r.Method, r.URL.RequestURI(), body)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	return nil
+}
+
+// RoundTripper 返回自动签名的 http.RoundTripper
+// 可用于 net/http 客户端或 Kratos HTTP 客户端（kratoshttp.WithTransport）
+// base 为 nil 时使用 http.DefaultTransport
+func (s *Signer) RoundTripper(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		// RoundTripper 不应修改原请求
+		r = r.Clone(r.Context())
+		if err := s.SignHTTPRequest(r); err != nil {
+			return nil, err
+		}
+		return base.RoundTrip(r)
+	})
+}
+
+// SignatureClient gRPC 客户端签名中间件，对 proto 请求的确定性序列化结果签名
+func SignatureClient(signer *Signer) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+
+			body, err := marshalSignedMessage(req)
+			if err != nil {
+				return nil, err
+			}
+			headers, err := signer.Headers(http.MethodPost, tr.Operation(), body)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range headers {
+				tr.RequestHeader().Set(k, v)
+			}
+
+			return handler(ctx, req)
+		}
+	}
+}
+
+// roundTripperFunc 函数形式的 http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip 实现 http.RoundTripper
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// newNonce 生成随机 nonce
+func newNonce() (string, error) {
+	b := make([]byte, signatureNonceByteCount)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
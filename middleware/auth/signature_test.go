@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/response"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestVerifySignature_HTTP(t *testing.T) {
+	config := &SignatureConfig{Secrets: map[string]string{"partner-1": "s3cret"}}
+	errorHandler := response.NewDefaultErrorHandler(response.WithStatusMapping(map[int]int{pkgErrors.ErrCodeUnauthorized: http.StatusUnauthorized}))
+	srv := kratoshttp.NewServer(
+		kratoshttp.Middleware(VerifySignature(config, NewMemoryNonceStore(), log.DefaultLogger)),
+		kratoshttp.ErrorEncoder(response.NewErrorEncoder(errorHandler)),
+	)
+	srv.Route("/").POST("/hooks/order", func(ctx kratoshttp.Context) error {
+		request := ctx.Request()
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			// 验证签名后 body 仍可被读取
+			body, err := io.ReadAll(request.Body)
+			return GetSignatureKeyIDFromContext(ctx) + ":" + string(body), err
+		})
+		out, err := h(ctx, nil)
+		if err != nil {
+			return err
+		}
+		return ctx.String(http.StatusOK, out.(string))
+	})
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	post := func(client *http.Client, body string) (*http.Response, string) {
+		resp, err := client.Post(server.URL+"/hooks/order?v=1", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+
+	signed := &http.Client{Transport: NewSigner("partner-1", "s3cret").RoundTripper(nil)}
+	resp, body := post(signed, `{"id":1}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `partner-1:{"id":1}`, body)
+
+	// 验证失败返回本地化的业务错误
+	resp, body = post(http.DefaultClient, `{"id":1}`)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, body, `"errorCode":"100101"`)
+	assert.Contains(t, body, "请先登录")
+
+	wrongSecret := &http.Client{Transport: NewSigner("partner-1", "other").RoundTripper(nil)}
+	resp, _ = post(wrongSecret, `{"id":1}`)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// 超过上限的 body 不能只按截断后的前缀验签
+	prefix := strings.Repeat("a", maxSignatureBodyBytes)
+	headers, err := NewSigner("partner-1", "s3cret").Headers(http.MethodPost, "/hooks/order?v=1", []byte(prefix))
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/hooks/order?v=1", strings.NewReader(prefix+"tail"))
+	require.NoError(t, err)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	// 签名方同样拒绝超过上限的 body
+	_, err = signed.Post(server.URL+"/hooks/order", "application/json", strings.NewReader(prefix+"tail"))
+	assert.ErrorIs(t, err, ErrSignatureBodyTooLarge)
+}
+
+func TestVerifySignature_ReplayAndSkew(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	config := &SignatureConfig{Secrets: map[string]string{"svc": "key"}, MaxClockSkew: time.Minute}
+	verify := VerifySignature(config, NewRedisNonceStore(rdb, ""), log.DefaultLogger)(
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return GetSignatureKeyIDFromContext(ctx), nil
+		})
+
+	req := wrapperspb.String("payload")
+	header := headerCarrier{}
+	clientCtx := transport.NewClientContext(context.Background(), &testTransport{header: header, operation: "/hook.v1.Hook/Notify"})
+	_, err := SignatureClient(NewSigner("svc", "key"))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})(clientCtx, req)
+	require.NoError(t, err)
+
+	call := func(req interface{}) (interface{}, error) {
+		ctx := transport.NewServerContext(context.Background(), &testTransport{header: header, operation: "/hook.v1.Hook/Notify"})
+		return verify(ctx, req)
+	}
+
+	keyID, err := call(req)
+	require.NoError(t, err)
+	assert.Equal(t, "svc", keyID)
+
+	// 重放
+	_, err = call(req)
+	assert.Error(t, err)
+
+	// 篡改请求体
+	mr.FlushAll()
+	_, err = call(wrapperspb.String("tampered"))
+	assert.Error(t, err)
+
+	// 超出时钟偏差
+	mr.FlushAll()
+	timeNow = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	defer func() { timeNow = time.Now }()
+	_, err = call(req)
+	assert.Error(t, err)
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	store := NewMemoryNonceStore()
+	ctx := context.Background()
+
+	fresh, err := store.Use(ctx, "n1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, fresh)
+	fresh, _ = store.Use(ctx, "n1", time.Minute)
+	assert.False(t, fresh)
+	_, _ = store.Use(ctx, "n2", 2*time.Minute)
+
+	// 过期的 nonce 被清理，未过期的保留
+	now = now.Add(90 * time.Second)
+	fresh, _ = store.Use(ctx, "n3", time.Minute)
+	assert.True(t, fresh)
+	assert.Len(t, store.nonces, 2)
+	fresh, _ = store.Use(ctx, "n2", time.Minute)
+	assert.False(t, fresh)
+	fresh, _ = store.Use(ctx, "n1", time.Minute)
+	assert.True(t, fresh)
+}
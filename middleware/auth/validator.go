@@ -0,0 +1,21 @@
+// Package auth 提供认证中间件和工具函数
+package auth
+
+import "context"
+
+// TokenValidator Token 验证器接口
+// 内置实现：
+// - PassportTokenValidator：通过 gRPC 调用 passport-service 验证
+// - JWTValidator：本地验证 JWT 签名和声明，无需网络调用
+type TokenValidator interface {
+	// ValidateToken 验证 token，返回用户声明信息
+	ValidateToken(ctx context.Context, token string) (*UserClaims, error)
+}
+
+// TokenValidatorFunc 函数适配器，便于在测试或简单场景中实现 TokenValidator
+type TokenValidatorFunc func(ctx context.Context, token string) (*UserClaims, error)
+
+// ValidateToken 实现 TokenValidator 接口
+func (f TokenValidatorFunc) ValidateToken(ctx context.Context, token string) (*UserClaims, error) {
+	return f(ctx, token)
+}
@@ -5,19 +5,45 @@ import (
 	"context"
 	"strings"
 
+	"github.com/gaoyong06/go-pkg/middleware/idcheck"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
 	"google.golang.org/grpc/metadata"
 )
 
+// Option 开发者 ID 中间件选项
+type Option func(*options)
+
+// options 开发者 ID 中间件选项
+type options struct {
+	checker *idcheck.Checker
+}
+
+// Require 启用开发者 ID 必填和格式校验，校验失败时返回本地化的业务错误（语法见 idcheck 包）
+// 例如：Middleware(Require(&idcheck.Config{RequirePaths: []string{"/open/**"}, MaxLength: 64}))
+func Require(config *idcheck.Config, opts ...idcheck.Option) Option {
+	return func(o *options) {
+		o.checker = idcheck.New("developer_id", config, opts...)
+	}
+}
+
 // Middleware 开发者 ID 中间件，提取开发者 ID 并存入 context
 // 开发者 ID 提取优先级：
 // 1. HTTP Header X-Developer-Id（由 API Gateway 的 api-key 插件设置）
 // 2. gRPC metadata X-Developer-Id（服务间调用时传递）
-func Middleware() middleware.Middleware {
+// 默认不要求必须携带开发者 ID，需要时使用 Require 选项
+func Middleware(opts ...Option) middleware.Middleware {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
 			developerID := extractDeveloperID(ctx)
+			if err := o.checker.Check(ctx, developerID); err != nil {
+				return nil, err
+			}
 			if developerID != "" {
 				ctx = WithDeveloperID(ctx, developerID)
 			}
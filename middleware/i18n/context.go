@@ -3,6 +3,8 @@ package i18n
 
 import (
 	"context"
+
+	"github.com/gaoyong06/go-pkg/utils"
 )
 
 // langKey 是 context 中存储语言信息的键
@@ -20,8 +22,47 @@ func Language(ctx context.Context) string {
 	return "zh-CN" // 默认语言
 }
 
+// RequestLanguage 获取当前请求的语言
+// 优先使用 context 中已设置的语言（由 Middleware 设置）；否则直接从请求中提取，
+// 适用于 HTTP 编码器等拿不到中间件 context 的场景（其 context 中仍有 transport 信息）
+func RequestLanguage(ctx context.Context) string {
+	if lang, ok := ctx.Value(LanguageKey).(string); ok && lang != "" {
+		return lang
+	}
+	return extractLanguage(ctx)
+}
+
 // WithLanguage 将语言存入 context
 func WithLanguage(ctx context.Context, lang string) context.Context {
 	return context.WithValue(ctx, LanguageKey, lang)
 }
 
+// timezoneKey 是 context 中存储用户时区的键
+type timezoneKey struct{}
+
+// TimezoneKey 导出时区键，供外部使用
+var TimezoneKey = timezoneKey{}
+
+// Timezone 从 context 中获取用户时区（IANA 时区名，如 "Asia/Shanghai"）
+// 如果 context 中没有时区信息，返回默认时区 "UTC"
+func Timezone(ctx context.Context) string {
+	if tz, ok := ctx.Value(TimezoneKey).(string); ok && tz != "" {
+		return tz
+	}
+	return utils.DefaultTimezone
+}
+
+// WithTimezone 将用户时区存入 context
+// 无效的时区名和 "Local" 会被忽略，context 保持不变
+func WithTimezone(ctx context.Context, timezone string) context.Context {
+	if !isUserTimezone(timezone) {
+		return ctx
+	}
+	return context.WithValue(ctx, TimezoneKey, timezone)
+}
+
+// isUserTimezone 是否为可作为用户时区的 IANA 时区名
+// time.LoadLocation("Local") 返回服务器本地时区，结果取决于部署环境，不能作为用户时区
+func isUserTimezone(timezone string) bool {
+	return timezone != "" && timezone != "Local" && utils.IsValidTimezone(timezone)
+}
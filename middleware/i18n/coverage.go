@@ -0,0 +1,457 @@
+// Package i18n 提供国际化（i18n）翻译覆盖率检查
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// 覆盖率问题类型
+const (
+	// CoverageMissing 参考语言中存在，目标语言中缺失
+	CoverageMissing = "missing"
+	// CoverageExtra 目标语言中存在，参考语言中没有
+	CoverageExtra = "extra"
+	// CoverageTemplateVars 模板变量与参考语言不一致
+	CoverageTemplateVars = "template_vars"
+	// CoveragePluralForms 复数类别与目标语言的复数规则不一致
+	CoveragePluralForms = "plural_forms"
+	// CoverageLoadError 语言目录或翻译文件无法读取、解析，Detail 为错误信息
+	CoverageLoadError = "load_error"
+)
+
+// CoverageIssue 单条翻译覆盖率问题
+type CoverageIssue struct {
+	Type   string // 问题类型，见 Coverage* 常量
+	Lang   string // 目标语言
+	File   string // 文件名（相对于语言目录）；missing 时为参考语言中的文件，语言目录无法读取时为空
+	Key    string // 消息 ID（嵌套键以 "." 连接，如 errors.100001）；load_error 时为空
+	Detail string // 问题详情
+}
+
+// String 返回便于阅读的问题描述
+func (i CoverageIssue) String() string {
+	s := fmt.Sprintf("[%s] %s/%s", i.Type, i.Lang, i.File)
+	if i.Key != "" {
+		s += ": " + i.Key
+	}
+	if i.Detail != "" {
+		s += " (" + i.Detail + ")"
+	}
+	return s
+}
+
+// CoverageReport 翻译覆盖率检查报告
+type CoverageReport struct {
+	Reference string          // 参考语言
+	Languages []string        // 参与比较的其他语言
+	Issues    []CoverageIssue // 所有问题，加载错误在前，其余按语言分组、消息 ID 排序
+
+	missing map[string][]*catalogEntry // lang -> 缺失的参考语言消息
+}
+
+// HasIssues 是否存在任何问题
+func (r *CoverageReport) HasIssues() bool {
+	return len(r.Issues) > 0
+}
+
+// catalogEntry 某个语言下的一条消息
+type catalogEntry struct {
+	file    string
+	message *i18n.Message
+	plural  bool // 文件中以复数形式（如 {"other": "..."}）定义
+}
+
+// CheckCoverage 以 reference 语言为基准检查 {root}/{lang}/ 下所有语言的翻译覆盖率
+// 同时适用于 BundleTranslator 的消息文件和 errors.JSONErrorMessageLoader 的 errors.json
+// （后者的键以 "errors.<code>" 的形式参与比较）
+// 单个语言目录或文件无法读取、解析时记为 load_error 问题，不影响其他文件的检查
+func CheckCoverage(fsys fs.FS, root, reference string) (*CoverageReport, error) {
+	catalogs, loadIssues, err := loadCatalogs(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+
+	refCatalog, ok := catalogs[reference]
+	if !ok {
+		return nil, fmt.Errorf("reference language %s not found in %s", reference, root)
+	}
+
+	report := &CoverageReport{
+		Reference: reference,
+		Issues:    loadIssues,
+		missing:   make(map[string][]*catalogEntry),
+	}
+	for lang := range catalogs {
+		if lang != reference {
+			report.Languages = append(report.Languages, lang)
+		}
+	}
+	sort.Strings(report.Languages)
+
+	for _, lang := range report.Languages {
+		catalog := catalogs[lang]
+		tag, _ := language.Parse(lang)
+
+		for _, id := range sortedKeys(refCatalog) {
+			ref := refCatalog[id]
+			entry, ok := catalog[id]
+			if !ok {
+				report.missing[lang] = append(report.missing[lang], ref)
+				report.Issues = append(report.Issues, CoverageIssue{Type: CoverageMissing, Lang: lang, File: ref.file, Key: id})
+				continue
+			}
+
+			if want, have := templateVars(ref.message), templateVars(entry.message); !slices.Equal(want, have) {
+				report.Issues = append(report.Issues, CoverageIssue{
+					Type: CoverageTemplateVars, Lang: lang, File: entry.file, Key: id,
+					Detail: fmt.Sprintf("have %v, want %v", have, want),
+				})
+			}
+
+			// 参考语言是复数消息时，目标语言即使写成普通字符串也要满足自己的复数规则
+			if ref.isPlural() || entry.isPlural() {
+				if want, have := pluralCategories(tag), messageForms(entry.message); !slices.Equal(want, have) {
+					report.Issues = append(report.Issues, CoverageIssue{
+						Type: CoveragePluralForms, Lang: lang, File: entry.file, Key: id,
+						Detail: fmt.Sprintf("have %v, want %v", have, want),
+					})
+				}
+			}
+		}
+
+		for _, id := range sortedKeys(catalog) {
+			if _, ok := refCatalog[id]; !ok {
+				report.Issues = append(report.Issues, CoverageIssue{Type: CoverageExtra, Lang: lang, File: catalog[id].file, Key: id})
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// Skeletons 为缺失的消息生成骨架文件
+// 返回 "{lang}/{file}.json" -> 文件内容，文件内容为参考语言的原文，待翻译人员替换
+func (r *CoverageReport) Skeletons() (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for lang, entries := range r.missing {
+		tag, _ := language.Parse(lang)
+
+		grouped := make(map[string]map[string]interface{}) // file -> 嵌套消息
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.file, path.Ext(entry.file)) + ".json"
+			if grouped[name] == nil {
+				grouped[name] = make(map[string]interface{})
+			}
+			setNested(grouped[name], entry.message.ID, skeletonValue(entry.message, entry.plural, tag))
+		}
+
+		for name, content := range grouped {
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(content); err != nil {
+				return nil, fmt.Errorf("encode skeleton %s/%s: %w", lang, name, err)
+			}
+			files[path.Join(lang, name)] = buf.Bytes()
+		}
+	}
+	return files, nil
+}
+
+// loadCatalogs 加载 {root}/{lang}/ 下的所有消息，返回 lang -> message ID -> 消息
+// 只有根目录无法读取时返回 error，单个语言目录或文件的错误作为 load_error 问题返回
+func loadCatalogs(fsys fs.FS, root string) (map[string]map[string]*catalogEntry, []CoverageIssue, error) {
+	entries, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read i18n dir %s: %w", root, err)
+	}
+
+	unmarshalFuncs := defaultUnmarshalFuncs()
+	catalogs := make(map[string]map[string]*catalogEntry)
+	var issues []CoverageIssue
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		lang := entry.Name()
+		if _, err := language.Parse(lang); err != nil {
+			issues = append(issues, CoverageIssue{Type: CoverageLoadError, Lang: lang, Detail: fmt.Sprintf("invalid language dir: %v", err)})
+			continue
+		}
+
+		langDir := path.Join(root, lang)
+		files, err := fs.ReadDir(fsys, langDir)
+		if err != nil {
+			issues = append(issues, CoverageIssue{Type: CoverageLoadError, Lang: lang, Detail: err.Error()})
+			continue
+		}
+
+		catalog := make(map[string]*catalogEntry)
+		for _, file := range files {
+			ext := path.Ext(file.Name())
+			if file.IsDir() || ext == "" || unmarshalFuncs[ext[1:]] == nil {
+				continue
+			}
+
+			entries, err := loadCatalogFile(fsys, path.Join(langDir, file.Name()), unmarshalFuncs)
+			if err != nil {
+				issues = append(issues, CoverageIssue{Type: CoverageLoadError, Lang: lang, File: file.Name(), Detail: err.Error()})
+				continue
+			}
+			for _, e := range entries {
+				if _, ok := catalog[e.message.ID]; !ok {
+					catalog[e.message.ID] = e
+				}
+			}
+		}
+		catalogs[lang] = catalog
+	}
+
+	return catalogs, issues, nil
+}
+
+// loadCatalogFile 解析单个翻译文件，并根据原始内容标记以复数形式定义的消息
+func loadCatalogFile(fsys fs.FS, filePath string, unmarshalFuncs map[string]i18n.UnmarshalFunc) ([]*catalogEntry, error) {
+	buf, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read message file %s: %w", filePath, err)
+	}
+
+	messageFile, err := i18n.ParseMessageFileBytes(buf, filePath, unmarshalFuncs)
+	if err != nil {
+		return nil, fmt.Errorf("parse message file %s: %w", filePath, err)
+	}
+
+	// 解析后的消息无法区分 "text" 和 {"other": "text"}，需要从原始内容判断
+	var raw interface{}
+	if err := unmarshalFuncs[path.Ext(filePath)[1:]](buf, &raw); err != nil {
+		return nil, fmt.Errorf("parse message file %s: %w", filePath, err)
+	}
+	plural := make(map[string]bool)
+	collectPluralIDs(raw, "", plural)
+
+	entries := make([]*catalogEntry, 0, len(messageFile.Messages))
+	for _, message := range messageFile.Messages {
+		entries = append(entries, &catalogEntry{
+			file:    path.Base(filePath),
+			message: message,
+			plural:  plural[message.ID],
+		})
+	}
+	return entries, nil
+}
+
+// collectPluralIDs 遍历原始消息文件，记录以复数形式定义的消息 ID
+// 定义了 other 以外复数类别的对象，或只包含复数类别键的对象（如 {"other": "..."}）视为复数消息
+func collectPluralIDs(value interface{}, prefix string, plural map[string]bool) {
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			collectPluralIDs(item, prefix, plural)
+		}
+		return
+	}
+
+	m := stringKeyMap(value)
+	if m == nil {
+		return
+	}
+
+	// 数组格式的消息以 id 字段作为消息 ID
+	if id, ok := m["id"].(string); ok {
+		prefix = id
+	}
+
+	hasOther, hasForms, onlyForms := false, false, true
+	for key, v := range m {
+		switch strings.ToLower(key) {
+		case "other":
+			hasOther = true
+		case "zero", "one", "two", "few", "many":
+			if text, ok := v.(string); ok && text != "" {
+				hasForms = true
+			}
+		default:
+			onlyForms = false
+		}
+	}
+	if hasOther || hasForms {
+		if prefix != "" && (hasForms || onlyForms) {
+			plural[prefix] = true
+		}
+		return
+	}
+
+	for key, child := range m {
+		id := key
+		if prefix != "" {
+			id = prefix + "." + key
+		}
+		collectPluralIDs(child, id, plural)
+	}
+}
+
+// stringKeyMap 将 JSON/TOML/YAML 解析出的对象统一为 map[string]interface{}，不是对象时返回 nil
+// YAML 中的数字键（如 errors: {100001: ...}）会解析为 map[interface{}]interface{}
+func stringKeyMap(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			m[fmt.Sprint(key)] = child
+		}
+		return m
+	}
+	return nil
+}
+
+// templateVarPattern 匹配模板动作 {{ ... }}
+var templateVarPattern = regexp.MustCompile(`\{\{(.*?)\}\}`)
+
+// templateFieldPattern 匹配模板动作中的字段引用，如 .Name
+var templateFieldPattern = regexp.MustCompile(`\.([A-Za-z_][A-Za-z0-9_]*)`)
+
+// templateVars 提取消息所有复数形式中引用的模板变量（去重、排序）
+func templateVars(message *i18n.Message) []string {
+	set := make(map[string]struct{})
+	for _, text := range []string{message.Zero, message.One, message.Two, message.Few, message.Many, message.Other} {
+		for _, action := range templateVarPattern.FindAllStringSubmatch(text, -1) {
+			for _, field := range templateFieldPattern.FindAllStringSubmatch(action[1], -1) {
+				set[field[1]] = struct{}{}
+			}
+		}
+	}
+	return sortedKeys(set)
+}
+
+// isPluralMessage 消息是否定义了 other 以外的复数形式
+func isPluralMessage(message *i18n.Message) bool {
+	return message.Zero != "" || message.One != "" || message.Two != "" || message.Few != "" || message.Many != ""
+}
+
+// isPlural 消息是否为复数消息：文件中以复数形式定义，或定义了 other 以外的复数形式
+func (e *catalogEntry) isPlural() bool {
+	return e.plural || isPluralMessage(e.message)
+}
+
+// messageForms 返回消息已定义的复数类别（按 CLDR 顺序）
+func messageForms(message *i18n.Message) []string {
+	var forms []string
+	for _, form := range pluralFormOrder {
+		if messageFormText(message, form) != "" {
+			forms = append(forms, form)
+		}
+	}
+	return forms
+}
+
+// pluralFormOrder CLDR 复数类别顺序
+var pluralFormOrder = []string{"zero", "one", "two", "few", "many", "other"}
+
+// messageFormText 返回消息指定复数类别的文本
+func messageFormText(message *i18n.Message, form string) string {
+	switch form {
+	case "zero":
+		return message.Zero
+	case "one":
+		return message.One
+	case "two":
+		return message.Two
+	case "few":
+		return message.Few
+	case "many":
+		return message.Many
+	default:
+		return message.Other
+	}
+}
+
+// pluralCategories 返回语言使用的基数复数类别（按 CLDR 顺序）
+// x/text 未导出类别列表，这里通过枚举整数和小数样本推导
+func pluralCategories(tag language.Tag) []string {
+	names := map[plural.Form]string{
+		plural.Zero: "zero", plural.One: "one", plural.Two: "two",
+		plural.Few: "few", plural.Many: "many", plural.Other: "other",
+	}
+
+	set := map[string]struct{}{"other": {}}
+	for n := 0; n <= 1000; n++ {
+		set[names[plural.Cardinal.MatchPlural(tag, n, 0, 0, 0, 0)]] = struct{}{}
+		set[names[plural.Cardinal.MatchPlural(tag, n, 1, 1, 5, 5)]] = struct{}{}
+	}
+	for _, n := range []int{1000000, 2000000} {
+		set[names[plural.Cardinal.MatchPlural(tag, n, 0, 0, 0, 0)]] = struct{}{}
+	}
+
+	var forms []string
+	for _, form := range pluralFormOrder {
+		if _, ok := set[form]; ok {
+			forms = append(forms, form)
+		}
+	}
+	return forms
+}
+
+// skeletonValue 生成骨架消息：普通消息为原文，复数消息按目标语言的复数类别展开
+func skeletonValue(message *i18n.Message, plural bool, tag language.Tag) interface{} {
+	if !isPluralMessage(message) && !plural {
+		return message.Other
+	}
+
+	forms := make(map[string]string)
+	for _, form := range pluralCategories(tag) {
+		text := messageFormText(message, form)
+		if text == "" {
+			text = message.Other
+		}
+		forms[form] = text
+	}
+	return forms
+}
+
+// setNested 按 "." 拆分消息 ID 写入嵌套 map；路径冲突时退化为扁平键
+func setNested(m map[string]interface{}, id string, value interface{}) {
+	parts := strings.Split(id, ".")
+	current := m
+	for i, part := range parts[:len(parts)-1] {
+		next, ok := current[part]
+		if !ok {
+			child := make(map[string]interface{})
+			current[part] = child
+			current = child
+			continue
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			current[strings.Join(parts[i:], ".")] = value
+			return
+		}
+		current = child
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+// sortedKeys 返回排序后的 map 键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
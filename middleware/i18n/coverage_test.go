@@ -0,0 +1,102 @@
+package i18n
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestCheckCoverage(t *testing.T) {
+	fsys := fstest.MapFS{
+		"i18n/zh-CN/messages.json": {Data: []byte(`{
+			"greeting": "你好，{{.Name}}",
+			"items": {"other": "{{.Count}} 个项目"},
+			"only_zh": "仅中文"
+		}`)},
+		"i18n/zh-CN/errors.json": {Data: []byte(`{"errors": {"100001": "参数无效"}}`)},
+		"i18n/en-US/messages.json": {Data: []byte(`{
+			"greeting": "Hello, {{.UserName}}",
+			"items": {"many": "{{.Count}} items", "other": "{{.Count}} items"},
+			"only_en": "English only"
+		}`)},
+	}
+
+	report, err := CheckCoverage(fsys, "i18n", "zh-CN")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en-US"}, report.Languages)
+
+	var got []string
+	for _, issue := range report.Issues {
+		got = append(got, issue.Type+":"+issue.Key)
+	}
+	assert.ElementsMatch(t, []string{
+		"missing:errors.100001",
+		"template_vars:greeting",
+		"plural_forms:items",
+		"missing:only_zh",
+		"extra:only_en",
+	}, got)
+
+	skeletons, err := report.Skeletons()
+	require.NoError(t, err)
+	require.Contains(t, skeletons, "en-US/errors.json")
+
+	var errorsFile map[string]map[string]string
+	require.NoError(t, json.Unmarshal(skeletons["en-US/errors.json"], &errorsFile))
+	assert.Equal(t, "参数无效", errorsFile["errors"]["100001"])
+}
+
+func TestCheckCoveragePluralPlainTarget(t *testing.T) {
+	fsys := fstest.MapFS{
+		"i18n/zh-CN/messages.json": {Data: []byte(`{
+			"items": {"other": "{{.Count}} 个项目"},
+			"title": {"description": "页面标题", "other": "订单"}
+		}`)},
+		"i18n/en-US/messages.yaml": {Data: []byte("items: \"{{.Count}} items\"\ntitle: Orders\n")},
+		"i18n/ja-JP/messages.json": {Data: []byte(`{"items": "{{.Count}} 件", "title": "注文"}`)},
+	}
+
+	report, err := CheckCoverage(fsys, "i18n", "zh-CN")
+	require.NoError(t, err)
+
+	// 英文需要 one/other 两种形式，日文只有 other；带 description 的普通消息不按复数检查
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, CoverageIssue{
+		Type: CoveragePluralForms, Lang: "en-US", File: "messages.yaml", Key: "items",
+		Detail: "have [other], want [one other]",
+	}, report.Issues[0])
+}
+
+func TestCheckCoverageLoadErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"i18n/zh-CN/messages.json": {Data: []byte(`{"greeting": "你好", "bye": "再见"}`)},
+		"i18n/en-US/messages.json": {Data: []byte(`{"greeting": "Hello"}`)},
+		"i18n/en-US/broken.json":   {Data: []byte(`{"bye": `)},
+		"i18n/not_a_lang!/a.json":  {Data: []byte(`{}`)},
+	}
+
+	report, err := CheckCoverage(fsys, "i18n", "zh-CN")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en-US"}, report.Languages)
+
+	var got []string
+	for _, issue := range report.Issues {
+		got = append(got, issue.Type+":"+issue.Lang+"/"+issue.File+":"+issue.Key)
+	}
+	// 单个文件的加载错误不影响同一语言其他文件的检查
+	assert.ElementsMatch(t, []string{
+		"load_error:en-US/broken.json:",
+		"load_error:not_a_lang!/:",
+		"missing:en-US/messages.json:bye",
+	}, got)
+	assert.Equal(t, CoverageLoadError, report.Issues[0].Type)
+}
+
+func TestPluralCategories(t *testing.T) {
+	assert.Equal(t, []string{"other"}, pluralCategories(language.MustParse("zh-CN")))
+	assert.Equal(t, []string{"one", "other"}, pluralCategories(language.MustParse("en-US")))
+}
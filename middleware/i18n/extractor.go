@@ -8,11 +8,19 @@ import (
 	"github.com/go-kratos/kratos/v2/transport"
 )
 
-// extractLanguage 从请求中提取语言
+// extractLanguage 从请求中提取语言，请求未指定语言时返回默认语言 zh-CN
 func extractLanguage(ctx context.Context) string {
+	if lang := requestedLanguage(ctx); lang != "" {
+		return lang
+	}
+	return "zh-CN" // 默认语言
+}
+
+// requestedLanguage 从请求中提取客户端明确指定的语言，未指定或不支持时返回空字符串
+func requestedLanguage(ctx context.Context) string {
 	tr, ok := transport.FromServerContext(ctx)
 	if !ok {
-		return "zh-CN"
+		return ""
 	}
 
 	// 1. 从 URL 路径提取（如 /zh/xxx 或 /en/xxx）
@@ -25,13 +33,30 @@ func extractLanguage(ctx context.Context) string {
 
 	// 2. 从 HTTP Header 提取
 	if acceptLang := strings.TrimSpace(tr.RequestHeader().Get("Accept-Language")); acceptLang != "" {
-		lang := parseAcceptLanguage(acceptLang)
-		if lang != "" {
-			return lang
-		}
+		return parseAcceptLanguage(acceptLang)
+	}
+	return ""
+}
+
+// HasRequestedLanguage 请求是否明确指定了语言（URL 路径前缀或 Accept-Language 中支持的语言）
+// 返回 false 时 Middleware 使用的是默认语言，上层可以替换为应用或用户的默认语言
+func HasRequestedLanguage(ctx context.Context) bool {
+	return requestedLanguage(ctx) != ""
+}
+
+// extractTimezone 从 HTTP Header X-Timezone 提取用户时区
+// 无效的时区名和 "Local"（服务器本地时区）会被忽略
+func extractTimezone(ctx context.Context) string {
+	tr, ok := transport.FromServerContext(ctx)
+	if !ok {
+		return ""
 	}
 
-	return "zh-CN" // 默认语言
+	timezone := strings.TrimSpace(tr.RequestHeader().Get("X-Timezone"))
+	if !isUserTimezone(timezone) {
+		return ""
+	}
+	return timezone
 }
 
 // parseAcceptLanguage 解析 Accept-Language header
@@ -0,0 +1,199 @@
+// Package i18n 提供与请求语言相关的本地化格式化函数
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gaoyong06/go-pkg/utils"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// timeNow 获取当前时间
+// 提取为变量方便测试时 mock
+var timeNow = time.Now
+
+// dateLayouts 各语言的日期时间格式：language base -> [日期, 日期时间, 时间]
+var dateLayouts = map[string][3]string{
+	"zh": {"2006年1月2日", "2006年1月2日 15:04", "15:04"},
+	"en": {"Jan 2, 2006", "Jan 2, 2006 3:04 PM", "3:04 PM"},
+}
+
+// defaultDateLayouts 未配置语言使用的格式（ISO 8601 风格）
+var defaultDateLayouts = [3]string{"2006-01-02", "2006-01-02 15:04", "15:04"}
+
+// printer 根据 context 中的语言创建 x/text 打印器
+func printer(ctx context.Context) *message.Printer {
+	return message.NewPrinter(languageTag(ctx))
+}
+
+// languageTag 从 context 中获取语言标签
+func languageTag(ctx context.Context) language.Tag {
+	return language.Make(Language(ctx))
+}
+
+// languageBase 从 context 中获取基础语言，如 zh-CN -> zh
+func languageBase(ctx context.Context) string {
+	base, _ := languageTag(ctx).Base()
+	return base.String()
+}
+
+// FormatNumber 按请求语言格式化数字（千分位、小数点）
+// decimals: 最多保留的小数位数
+// 如 zh-CN/en-US: 1,234,567.89；de-DE: 1.234.567,89
+func FormatNumber(ctx context.Context, value float64, decimals int) string {
+	return printer(ctx).Sprint(number.Decimal(value, number.MaxFractionDigits(decimals)))
+}
+
+// FormatPercent 按请求语言格式化百分比
+// ratio: 比例值，如 0.123 表示 12.3%
+// decimals: 最多保留的小数位数
+func FormatPercent(ctx context.Context, ratio float64, decimals int) string {
+	return printer(ctx).Sprint(number.Percent(ratio, number.MaxFractionDigits(decimals)))
+}
+
+// FormatCurrency 按请求语言格式化金额
+// currencyCode: ISO 4217 货币代码，如 "CNY"、"USD"
+// 小数位数遵循货币标准（如 CNY/USD 保留 2 位，JPY 保留 0 位）
+// 货币符号默认紧贴在金额前，如 en-US/zh-CN: $1,234.50；
+// suffixCurrencyLanguages 中的语言放在金额后并以不换行空格分隔，如 de-DE: 1.234,50 €
+func FormatCurrency(ctx context.Context, amount float64, currencyCode string) (string, error) {
+	unit, err := currency.ParseISO(currencyCode)
+	if err != nil {
+		return "", fmt.Errorf("invalid currency code %s: %w", currencyCode, err)
+	}
+
+	// x/text 固定输出 "符号 金额"，不符合各语言的习惯，这里分别格式化符号和数字
+	p := printer(ctx)
+	scale, _ := currency.Standard.Rounding(unit)
+	symbol := p.Sprint(currency.NarrowSymbol(unit))
+	// 四舍五入（number.Scale 使用银行家舍入）
+	pow := math.Pow10(scale)
+	rounded := math.Round(math.Abs(amount)*pow) / pow
+	digits := p.Sprint(number.Decimal(rounded, number.Scale(scale)))
+
+	sign := ""
+	if amount < 0 && strings.ContainsAny(digits, "123456789") {
+		sign = "-"
+	}
+	if suffixCurrencyLanguages[languageBase(ctx)] {
+		return sign + digits + "\u00a0" + symbol, nil
+	}
+	return sign + symbol + digits, nil
+}
+
+// suffixCurrencyLanguages 货币符号放在金额之后的语言（CLDR 货币格式），key 为 language base
+var suffixCurrencyLanguages = map[string]bool{
+	"de": true, "fr": true, "es": true, "it": true, "ru": true,
+	"pl": true, "cs": true, "sv": true, "fi": true,
+}
+
+// FormatDate 按请求语言和时区格式化日期，如 "2024年3月5日" / "Mar 5, 2024"
+func FormatDate(ctx context.Context, t time.Time) string {
+	return localTime(ctx, t).Format(layoutsFor(ctx)[0])
+}
+
+// FormatDateTime 按请求语言和时区格式化日期时间，如 "2024年3月5日 14:30" / "Mar 5, 2024 2:30 PM"
+func FormatDateTime(ctx context.Context, t time.Time) string {
+	return localTime(ctx, t).Format(layoutsFor(ctx)[1])
+}
+
+// FormatClock 按请求语言和时区格式化时间，如 "14:30" / "2:30 PM"
+func FormatClock(ctx context.Context, t time.Time) string {
+	return localTime(ctx, t).Format(layoutsFor(ctx)[2])
+}
+
+// localTime 将时间转换为 context 中的用户时区
+func localTime(ctx context.Context, t time.Time) time.Time {
+	return utils.ConvertFromUTC(t.UTC(), Timezone(ctx))
+}
+
+// layoutsFor 获取请求语言对应的日期时间格式
+func layoutsFor(ctx context.Context) [3]string {
+	if layouts, ok := dateLayouts[languageBase(ctx)]; ok {
+		return layouts
+	}
+	return defaultDateLayouts
+}
+
+// relativeUnit 相对时间单位
+type relativeUnit struct {
+	duration time.Duration
+	zh       string
+	en       string
+}
+
+// relativeUnits 相对时间单位，从大到小
+var relativeUnits = []relativeUnit{
+	{365 * 24 * time.Hour, "年", "year"},
+	{30 * 24 * time.Hour, "个月", "month"},
+	{24 * time.Hour, "天", "day"},
+	{time.Hour, "小时", "hour"},
+	{time.Minute, "分钟", "minute"},
+}
+
+// relativeFormatter 按单位和数量格式化相对时间，unit 为 nil 表示不足 1 分钟
+type relativeFormatter func(unit *relativeUnit, count int64, future bool) string
+
+// relativeFormatters 支持相对时间格式化的语言：language base -> 格式化函数
+var relativeFormatters = map[string]relativeFormatter{
+	"zh": formatRelativeZh,
+	"en": formatRelativeEn,
+}
+
+// defaultRelativeLanguage 未支持的语言使用的相对时间语言
+const defaultRelativeLanguage = "en"
+
+// FormatRelativeTime 按请求语言格式化相对于当前时间的时间
+// 如 "刚刚" / "just now"、"3分钟前" / "3 minutes ago"、"2天后" / "in 2 days"
+// 不足 1 分钟视为"刚刚"；目前只支持中文和英文，其他语言回退为英文
+func FormatRelativeTime(ctx context.Context, t time.Time) string {
+	diff := timeNow().Sub(t)
+	future := diff < 0
+	diff = time.Duration(math.Abs(float64(diff)))
+
+	format, ok := relativeFormatters[languageBase(ctx)]
+	if !ok {
+		format = relativeFormatters[defaultRelativeLanguage]
+	}
+
+	for i := range relativeUnits {
+		unit := &relativeUnits[i]
+		if diff >= unit.duration {
+			return format(unit, int64(diff/unit.duration), future)
+		}
+	}
+	return format(nil, 0, future)
+}
+
+// formatRelativeZh 中文相对时间，如 "3分钟前"、"2天后"
+func formatRelativeZh(unit *relativeUnit, count int64, future bool) string {
+	if unit == nil {
+		return "刚刚"
+	}
+	if future {
+		return fmt.Sprintf("%d%s后", count, unit.zh)
+	}
+	return fmt.Sprintf("%d%s前", count, unit.zh)
+}
+
+// formatRelativeEn 英文相对时间，如 "3 minutes ago"、"in 2 days"
+func formatRelativeEn(unit *relativeUnit, count int64, future bool) string {
+	if unit == nil {
+		return "just now"
+	}
+	name := unit.en
+	if count != 1 {
+		name += "s"
+	}
+	if future {
+		return fmt.Sprintf("in %d %s", count, name)
+	}
+	return fmt.Sprintf("%d %s ago", count, name)
+}
@@ -0,0 +1,83 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatNumbers(t *testing.T) {
+	zh := WithLanguage(context.Background(), "zh-CN")
+	de := WithLanguage(context.Background(), "de-DE")
+
+	assert.Equal(t, "1,234,567.89", FormatNumber(zh, 1234567.891, 2))
+	assert.Equal(t, "1.234.567,89", FormatNumber(de, 1234567.891, 2))
+	assert.Equal(t, "12.3%", FormatPercent(zh, 0.1234, 1))
+
+	en := WithLanguage(context.Background(), "en-US")
+	currencies := []struct {
+		ctx    context.Context
+		amount float64
+		code   string
+		want   string
+	}{
+		{en, 1234.5, "USD", "$1,234.50"},
+		{en, -1234.5, "USD", "-$1,234.50"},
+		{en, 1234.5, "JPY", "¥1,235"},
+		{zh, 1234.5, "CNY", "¥1,234.50"},
+		{de, 1234.5, "EUR", "1.234,50\u00a0€"},
+	}
+	for _, tt := range currencies {
+		amount, err := FormatCurrency(tt.ctx, tt.amount, tt.code)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, amount)
+	}
+
+	_, err := FormatCurrency(zh, 1, "XXXX")
+	assert.Error(t, err)
+}
+
+func TestFormatDateTime(t *testing.T) {
+	ts := time.Date(2024, 3, 5, 6, 30, 0, 0, time.UTC)
+	zh := WithTimezone(WithLanguage(context.Background(), "zh-CN"), "Asia/Shanghai")
+	en := WithLanguage(context.Background(), "en-US")
+
+	assert.Equal(t, "2024年3月5日", FormatDate(zh, ts))
+	assert.Equal(t, "2024年3月5日 14:30", FormatDateTime(zh, ts))
+	assert.Equal(t, "Mar 5, 2024 6:30 AM", FormatDateTime(en, ts))
+	assert.Equal(t, "6:30 AM", FormatClock(en, ts))
+}
+
+func TestFormatRelativeTime(t *testing.T) {
+	now := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	zh := WithLanguage(context.Background(), "zh-CN")
+	en := WithLanguage(context.Background(), "en-US")
+
+	assert.Equal(t, "刚刚", FormatRelativeTime(zh, now.Add(-10*time.Second)))
+	assert.Equal(t, "3分钟前", FormatRelativeTime(zh, now.Add(-3*time.Minute)))
+	assert.Equal(t, "2天后", FormatRelativeTime(zh, now.Add(49*time.Hour)))
+	assert.Equal(t, "just now", FormatRelativeTime(en, now))
+	assert.Equal(t, "1 hour ago", FormatRelativeTime(en, now.Add(-time.Hour)))
+	assert.Equal(t, "in 3 minutes", FormatRelativeTime(en, now.Add(3*time.Minute)))
+
+	// 未支持的语言回退为英文
+	de := WithLanguage(context.Background(), "de-DE")
+	assert.Equal(t, "2 days ago", FormatRelativeTime(de, now.Add(-49*time.Hour)))
+	assert.Equal(t, "just now", FormatRelativeTime(de, now))
+}
+
+func TestWithTimezone(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "Asia/Shanghai", Timezone(WithTimezone(ctx, "Asia/Shanghai")))
+
+	// 服务器本地时区和无效时区名不能作为用户时区
+	assert.Equal(t, "UTC", Timezone(WithTimezone(ctx, "Local")))
+	assert.Equal(t, "UTC", Timezone(WithTimezone(ctx, "Mars/Olympus")))
+	assert.False(t, isUserTimezone(""))
+}
@@ -12,11 +12,15 @@ import (
 // 1. URL 路径（如 /zh/xxx 或 /en/xxx）
 // 2. HTTP Header Accept-Language
 // 3. 默认语言 zh-CN
+// 同时从 HTTP Header X-Timezone 提取用户时区（IANA 时区名），供本地化格式化函数使用
 func Middleware() middleware.Middleware {
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
 			lang := extractLanguage(ctx)
 			ctx = WithLanguage(ctx, lang)
+			if timezone := extractTimezone(ctx); timezone != "" {
+				ctx = WithTimezone(ctx, timezone)
+			}
 			return handler(ctx, req)
 		}
 	}
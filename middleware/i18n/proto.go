@@ -0,0 +1,143 @@
+// Package i18n 提供 proto 枚举值和展示字段的本地化
+package i18n
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// defaultDisplayFieldSuffix 默认的展示字段后缀
+// 如枚举字段 status 对应的展示字段为 status_display
+const defaultDisplayFieldSuffix = "_display"
+
+// ProtoLocalizer proto 消息本地化器
+// 按照 enum.<FullName>.<VALUE> 的键约定，通过 Translator 翻译枚举值，
+// 并填充消息中与枚举字段对应的展示字段
+type ProtoLocalizer struct {
+	translator         Translator
+	displayFieldSuffix string
+}
+
+// ProtoLocalizerOption ProtoLocalizer 的可选参数
+type ProtoLocalizerOption func(*ProtoLocalizer)
+
+// WithDisplayFieldSuffix 设置展示字段后缀，默认 "_display"
+func WithDisplayFieldSuffix(suffix string) ProtoLocalizerOption {
+	return func(l *ProtoLocalizer) {
+		l.displayFieldSuffix = suffix
+	}
+}
+
+// NewProtoLocalizer 创建 proto 消息本地化器
+// translator: 翻译器，如 BundleTranslator
+func NewProtoLocalizer(translator Translator, opts ...ProtoLocalizerOption) *ProtoLocalizer {
+	l := &ProtoLocalizer{
+		translator:         translator,
+		displayFieldSuffix: defaultDisplayFieldSuffix,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// EnumKey 返回枚举值的翻译键，格式为 enum.<枚举全名>.<枚举值名>
+// 如 enum.passport.v1.UserStatus.USER_STATUS_ACTIVE
+func EnumKey(value protoreflect.EnumValueDescriptor) string {
+	return "enum." + string(value.Parent().FullName()) + "." + string(value.Name())
+}
+
+// TranslateEnum 按请求语言翻译枚举值
+// 找不到翻译时返回枚举值名
+func (l *ProtoLocalizer) TranslateEnum(ctx context.Context, value protoreflect.Enum) string {
+	return l.translateEnumNumber(ctx, value.Descriptor(), value.Number())
+}
+
+// translateEnumNumber 按枚举描述和数值翻译
+func (l *ProtoLocalizer) translateEnumNumber(ctx context.Context, enum protoreflect.EnumDescriptor, number protoreflect.EnumNumber) string {
+	value := enum.Values().ByNumber(number)
+	if value == nil {
+		// 未知枚举值（如新版本新增），直接返回数值
+		return fmt.Sprintf("%d", number)
+	}
+
+	key := EnumKey(value)
+	if translated := l.translator.Translate(ctx, key, nil); translated != "" && translated != key {
+		return translated
+	}
+	return string(value.Name())
+}
+
+// Localize 按请求语言填充消息（含嵌套消息、repeated 和 map 中的消息）中的展示字段
+// 对于枚举字段 xxx，如果消息中存在 string 类型的 xxx{后缀} 字段，则写入枚举值的翻译；
+// repeated 枚举字段对应 repeated string 展示字段
+func (l *ProtoLocalizer) Localize(ctx context.Context, msg proto.Message) {
+	if msg == nil {
+		return
+	}
+	l.localizeMessage(ctx, msg.ProtoReflect())
+}
+
+// localizeMessage 递归处理单个消息
+func (l *ProtoLocalizer) localizeMessage(ctx context.Context, m protoreflect.Message) {
+	if !m.IsValid() {
+		return
+	}
+
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+
+		switch {
+		case field.Kind() == protoreflect.EnumKind && !field.IsMap():
+			l.fillDisplayField(ctx, m, field)
+
+		case field.Kind() == protoreflect.MessageKind || field.Kind() == protoreflect.GroupKind:
+			if !m.Has(field) {
+				continue
+			}
+			switch {
+			case field.IsList():
+				list := m.Get(field).List()
+				for j := 0; j < list.Len(); j++ {
+					l.localizeMessage(ctx, list.Get(j).Message())
+				}
+			case field.IsMap():
+				if field.MapValue().Kind() != protoreflect.MessageKind {
+					continue
+				}
+				m.Get(field).Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+					l.localizeMessage(ctx, v.Message())
+					return true
+				})
+			default:
+				l.localizeMessage(ctx, m.Get(field).Message())
+			}
+		}
+	}
+}
+
+// fillDisplayField 为枚举字段填充对应的展示字段
+func (l *ProtoLocalizer) fillDisplayField(ctx context.Context, m protoreflect.Message, field protoreflect.FieldDescriptor) {
+	display := m.Descriptor().Fields().ByName(field.Name() + protoreflect.Name(l.displayFieldSuffix))
+	if display == nil || display.Kind() != protoreflect.StringKind || display.IsList() != field.IsList() || display.IsMap() {
+		return
+	}
+
+	if !field.IsList() {
+		text := l.translateEnumNumber(ctx, field.Enum(), m.Get(field).Enum())
+		m.Set(display, protoreflect.ValueOfString(text))
+		return
+	}
+
+	values := m.Get(field).List()
+	texts := m.Mutable(display).List()
+	texts.Truncate(0)
+	for j := 0; j < values.Len(); j++ {
+		text := l.translateEnumNumber(ctx, field.Enum(), values.Get(j).Enum())
+		texts.Append(protoreflect.ValueOfString(text))
+	}
+}
@@ -0,0 +1,100 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// newOrderDescriptor 构建测试用的 Order 消息描述：
+//
+//	enum Status { STATUS_UNSPECIFIED = 0; STATUS_PAID = 1; }
+//	message Order {
+//	  Status status = 1; string status_display = 2;
+//	  repeated Status history = 3; repeated string history_display = 4;
+//	  Order child = 5;
+//	}
+func newOrderDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, label descriptorpb.FieldDescriptorProto_Label, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    label.Enum(),
+			Type:     typ.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/order.proto"),
+		Package: proto.String("test.v1"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("STATUS_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("STATUS_PAID"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Order"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("status", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".test.v1.Status"),
+				field("status_display", 2, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("history", 3, repeated, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".test.v1.Status"),
+				field("history_display", 4, repeated, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("child", 5, optional, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".test.v1.Order"),
+			},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	return file.Messages().ByName("Order")
+}
+
+func TestProtoLocalizer_Localize(t *testing.T) {
+	translator, err := NewBundleTranslatorFS(fstest.MapFS{
+		"i18n/en-US/enum.json": {Data: []byte(`{"enum.test.v1.Status.STATUS_PAID": "Paid"}`)},
+	}, "i18n", WithStrictMode())
+	require.NoError(t, err)
+
+	desc := newOrderDescriptor(t)
+	fields := desc.Fields()
+	order := dynamicpb.NewMessage(desc)
+	order.Set(fields.ByName("status"), protoreflect.ValueOfEnum(1))
+	history := order.Mutable(fields.ByName("history")).List()
+	history.Append(protoreflect.ValueOfEnum(0))
+	history.Append(protoreflect.ValueOfEnum(1))
+	child := dynamicpb.NewMessage(desc)
+	child.Set(fields.ByName("status"), protoreflect.ValueOfEnum(1))
+	order.Set(fields.ByName("child"), protoreflect.ValueOfMessage(child))
+
+	ctx := WithLanguage(context.Background(), "en-US")
+	NewProtoLocalizer(translator).Localize(ctx, order)
+
+	assert.Equal(t, "Paid", order.Get(fields.ByName("status_display")).String())
+	displays := order.Get(fields.ByName("history_display")).List()
+	require.Equal(t, 2, displays.Len())
+	assert.Equal(t, "STATUS_UNSPECIFIED", displays.Get(0).String())
+	assert.Equal(t, "Paid", displays.Get(1).String())
+	assert.Equal(t, "Paid", child.Get(fields.ByName("status_display")).String())
+}
+
+func TestEnumKey(t *testing.T) {
+	desc := newOrderDescriptor(t)
+	value := desc.Fields().ByName("status").Enum().Values().ByNumber(1)
+	assert.Equal(t, "enum.test.v1.Status.STATUS_PAID", EnumKey(value))
+}
@@ -4,66 +4,202 @@ package i18n
 import (
 	"context"
 	"encoding/json"
-	"io/ioutil"
-	"path/filepath"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
 	"sync"
 
+	"github.com/BurntSushi/toml"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
 )
 
 // BundleTranslator 基于 go-i18n Bundle 的翻译器实现
+// 翻译文件目录结构：{root}/{lang}/*.{json,yaml,yml,toml}
+// 语言由目录名决定（如 zh-CN、en-US），与文件名无关
 type BundleTranslator struct {
 	bundle *i18n.Bundle
 	mutex  sync.RWMutex
+
+	fsys          fs.FS                         // 翻译文件所在的文件系统
+	root          string                        // 翻译文件根目录（相对于 fsys）
+	strict        bool                          // 严格模式：加载出错时返回聚合错误
+	unmarshalFunc map[string]i18n.UnmarshalFunc // 文件扩展名 -> 反序列化函数
+	loadErrors    []error                       // 最近一次加载时遇到的错误
+}
+
+// BundleOption BundleTranslator 的可选参数
+type BundleOption func(*BundleTranslator)
+
+// WithStrictMode 启用严格模式
+// 严格模式下，目录/文件读取失败、文件解析失败、同一语言下消息 ID 重复
+// 都会作为聚合错误返回，而不是被忽略
+func WithStrictMode() BundleOption {
+	return func(t *BundleTranslator) {
+		t.strict = true
+	}
+}
+
+// WithUnmarshalFunc 注册自定义格式的反序列化函数
+// format: 文件扩展名（不含点），如 "ini"
+func WithUnmarshalFunc(format string, unmarshalFunc i18n.UnmarshalFunc) BundleOption {
+	return func(t *BundleTranslator) {
+		t.unmarshalFunc[format] = unmarshalFunc
+	}
 }
 
 // NewBundleTranslator 创建 Bundle 翻译器
 // configDir: 配置文件目录（如 "." 表示当前目录）
-// 会自动加载 configDir/i18n/{lang}/*.json 文件
-func NewBundleTranslator(configDir string) (*BundleTranslator, error) {
+// 会自动加载 configDir/i18n/{lang}/*.{json,yaml,yml,toml} 文件
+func NewBundleTranslator(configDir string, opts ...BundleOption) (*BundleTranslator, error) {
+	return NewBundleTranslatorFS(os.DirFS(configDir), "i18n", opts...)
+}
+
+// NewBundleTranslatorFS 从 fs.FS 创建 Bundle 翻译器，适用于 embed.FS
+// fsys: 文件系统，如 //go:embed i18n 声明的 embed.FS
+// root: 翻译文件根目录（相对于 fsys），如 "i18n"
+// 非严格模式下总是返回 nil 错误，加载错误可通过 LoadErrors 获取
+func NewBundleTranslatorFS(fsys fs.FS, root string, opts ...BundleOption) (*BundleTranslator, error) {
+	t := &BundleTranslator{
+		fsys:          fsys,
+		root:          root,
+		unmarshalFunc: defaultUnmarshalFuncs(),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if err := t.Reload(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Reload 重新加载翻译文件，加载完成后原子替换当前 Bundle
+// 严格模式下如果加载出错，保留原 Bundle 并返回聚合错误；
+// 非严格模式下忽略出错的文件，错误可通过 LoadErrors 获取
+func (t *BundleTranslator) Reload() error {
+	bundle, loadErrors := t.load()
+	if t.strict && len(loadErrors) > 0 {
+		return fmt.Errorf("load i18n messages failed: %w", errors.Join(loadErrors...))
+	}
+
+	t.mutex.Lock()
+	t.bundle = bundle
+	t.loadErrors = loadErrors
+	t.mutex.Unlock()
+
+	return nil
+}
+
+// LoadErrors 返回最近一次成功加载时被忽略的错误（仅非严格模式下可能非空）
+func (t *BundleTranslator) LoadErrors() []error {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return append([]error(nil), t.loadErrors...)
+}
+
+// load 从文件系统构建新的 Bundle，返回加载过程中遇到的所有错误
+func (t *BundleTranslator) load() (*i18n.Bundle, []error) {
 	bundle := i18n.NewBundle(language.Chinese)
-	bundle.RegisterUnmarshalFunc("json", json.Unmarshal)
-
-	// 加载所有语言的翻译文件
-	i18nDir := filepath.Join(configDir, "i18n")
-	entries, err := ioutil.ReadDir(i18nDir)
-	if err == nil {
-		for _, entry := range entries {
-			if entry.IsDir() {
-				lang := entry.Name()
-				// 加载该语言目录下的所有 JSON 文件
-				langDir := filepath.Join(i18nDir, lang)
-				files, err := ioutil.ReadDir(langDir)
-				if err == nil {
-					for _, file := range files {
-						if !file.IsDir() && filepath.Ext(file.Name()) == ".json" {
-							filePath := filepath.Join(langDir, file.Name())
-							_, err := bundle.LoadMessageFile(filePath)
-							if err != nil {
-								// 忽略加载错误，继续加载其他文件
-								continue
-							}
-						}
-					}
+	for format, unmarshalFunc := range t.unmarshalFunc {
+		bundle.RegisterUnmarshalFunc(format, unmarshalFunc)
+	}
+
+	entries, err := fs.ReadDir(t.fsys, t.root)
+	if err != nil {
+		return bundle, []error{fmt.Errorf("read i18n dir %s: %w", t.root, err)}
+	}
+
+	var loadErrors []error
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		lang := entry.Name()
+		tag, err := language.Parse(lang)
+		if err != nil {
+			loadErrors = append(loadErrors, fmt.Errorf("invalid language dir %s: %w", lang, err))
+			continue
+		}
+
+		// 加载该语言目录下的所有翻译文件
+		langDir := path.Join(t.root, lang)
+		files, err := fs.ReadDir(t.fsys, langDir)
+		if err != nil {
+			loadErrors = append(loadErrors, fmt.Errorf("read language dir %s: %w", langDir, err))
+			continue
+		}
+
+		seen := make(map[string]string) // message ID -> 首次定义的文件
+		for _, file := range files {
+			ext := path.Ext(file.Name())
+			if file.IsDir() || ext == "" || t.unmarshalFunc[ext[1:]] == nil {
+				continue
+			}
+
+			filePath := path.Join(langDir, file.Name())
+			messages, err := parseMessageFile(t.fsys, filePath, t.unmarshalFunc)
+			if err != nil {
+				loadErrors = append(loadErrors, err)
+				continue
+			}
+
+			for _, message := range messages {
+				if first, ok := seen[message.ID]; ok {
+					loadErrors = append(loadErrors, fmt.Errorf("duplicate message id %q in %s: already defined in %s", message.ID, filePath, first))
+					continue
 				}
+				seen[message.ID] = filePath
+			}
+
+			if err := bundle.AddMessages(tag, messages...); err != nil {
+				loadErrors = append(loadErrors, fmt.Errorf("add messages from %s: %w", filePath, err))
 			}
 		}
 	}
 
-	return &BundleTranslator{
-		bundle: bundle,
-	}, nil
+	return bundle, loadErrors
+}
+
+// defaultUnmarshalFuncs 默认支持的翻译文件格式
+func defaultUnmarshalFuncs() map[string]i18n.UnmarshalFunc {
+	return map[string]i18n.UnmarshalFunc{
+		"json": json.Unmarshal,
+		"yaml": yaml.Unmarshal,
+		"yml":  yaml.Unmarshal,
+		"toml": toml.Unmarshal,
+	}
+}
+
+// parseMessageFile 读取并解析单个翻译文件
+func parseMessageFile(fsys fs.FS, filePath string, unmarshalFuncs map[string]i18n.UnmarshalFunc) ([]*i18n.Message, error) {
+	buf, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read message file %s: %w", filePath, err)
+	}
+
+	messageFile, err := i18n.ParseMessageFileBytes(buf, filePath, unmarshalFuncs)
+	if err != nil {
+		return nil, fmt.Errorf("parse message file %s: %w", filePath, err)
+	}
+
+	return messageFile.Messages, nil
 }
 
 // Translate 实现 Translator 接口
 func (t *BundleTranslator) Translate(ctx context.Context, key string, templateData map[string]interface{}) string {
 	lang := Language(ctx)
-	
+
 	t.mutex.RLock()
 	bundle := t.bundle
 	t.mutex.RUnlock()
-	
+
 	if bundle == nil {
 		return key
 	}
@@ -91,11 +227,11 @@ func (t *BundleTranslator) Translate(ctx context.Context, key string, templateDa
 // TranslateWithDefault 带默认值的翻译函数
 func (t *BundleTranslator) TranslateWithDefault(ctx context.Context, key string, defaultMessage string, templateData map[string]interface{}) string {
 	lang := Language(ctx)
-	
+
 	t.mutex.RLock()
 	bundle := t.bundle
 	t.mutex.RUnlock()
-	
+
 	if bundle == nil {
 		return defaultMessage
 	}
@@ -117,4 +253,3 @@ func (t *BundleTranslator) TranslateWithDefault(ctx context.Context, key string,
 
 	return translated
 }
-
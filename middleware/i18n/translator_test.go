@@ -0,0 +1,80 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundleTranslator_LoadFormats(t *testing.T) {
+	fsys := fstest.MapFS{
+		"i18n/zh-CN/messages.json": {Data: []byte(`{"greeting": "你好，{{.Name}}"}`)},
+		"i18n/en-US/messages.yaml": {Data: []byte("greeting: \"Hello, {{.Name}}\"\n")},
+		"i18n/en-US/extra.toml":    {Data: []byte("farewell = \"Bye\"\n")},
+	}
+
+	translator, err := NewBundleTranslatorFS(fsys, "i18n", WithStrictMode())
+	require.NoError(t, err)
+
+	zh := WithLanguage(context.Background(), "zh-CN")
+	en := WithLanguage(context.Background(), "en-US")
+	data := map[string]interface{}{"Name": "Tom"}
+
+	assert.Equal(t, "你好，Tom", translator.Translate(zh, "greeting", data))
+	assert.Equal(t, "Hello, Tom", translator.Translate(en, "greeting", data))
+	assert.Equal(t, "Bye", translator.Translate(en, "farewell", nil))
+	assert.Equal(t, "missing", translator.Translate(en, "missing", nil))
+}
+
+func TestBundleTranslator_Reload(t *testing.T) {
+	fsys := fstest.MapFS{
+		"i18n/en-US/messages.json": {Data: []byte(`{"title": "Old"}`)},
+	}
+
+	translator, err := NewBundleTranslatorFS(fsys, "i18n")
+	require.NoError(t, err)
+
+	ctx := WithLanguage(context.Background(), "en-US")
+	assert.Equal(t, "Old", translator.Translate(ctx, "title", nil))
+
+	fsys["i18n/en-US/messages.json"] = &fstest.MapFile{Data: []byte(`{"title": "New"}`)}
+	require.NoError(t, translator.Reload())
+	assert.Equal(t, "New", translator.Translate(ctx, "title", nil))
+}
+
+func TestBundleTranslator_StrictMode(t *testing.T) {
+	fsys := fstest.MapFS{
+		"i18n/en-US/a.json":   {Data: []byte(`{"title": "A"}`)},
+		"i18n/en-US/b.json":   {Data: []byte(`{"title": "B"}`)},
+		"i18n/en-US/bad.json": {Data: []byte(`{"title": `)},
+	}
+
+	// 非严格模式：忽略错误，但可以获取加载错误
+	translator, err := NewBundleTranslatorFS(fsys, "i18n")
+	require.NoError(t, err)
+	assert.Len(t, translator.LoadErrors(), 2)
+
+	// 严格模式：返回聚合错误
+	_, err = NewBundleTranslatorFS(fsys, "i18n", WithStrictMode())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `duplicate message id "title"`)
+	assert.Contains(t, err.Error(), "bad.json")
+}
+
+func TestBundleTranslator_StrictReloadKeepsBundle(t *testing.T) {
+	fsys := fstest.MapFS{
+		"i18n/en-US/messages.json": {Data: []byte(`{"title": "Old"}`)},
+	}
+
+	translator, err := NewBundleTranslatorFS(fsys, "i18n", WithStrictMode())
+	require.NoError(t, err)
+
+	fsys["i18n/en-US/messages.json"] = &fstest.MapFile{Data: []byte(`not json`)}
+	require.Error(t, translator.Reload())
+
+	ctx := WithLanguage(context.Background(), "en-US")
+	assert.Equal(t, "Old", translator.Translate(ctx, "title", nil))
+}
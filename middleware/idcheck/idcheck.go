@@ -0,0 +1,179 @@
+// Package idcheck 提供 app_id、user_id、developer_id 等身份中间件共用的必填和格式校验
+//
+// 用法：
+//
+//	app_id.Middleware(app_id.Require(&idcheck.Config{Required: true, SkipPaths: []string{"/health"}}))
+//	user_id.Middleware(user_id.Require(&idcheck.Config{RequirePaths: []string{"/v1/me/**"}, Format: idcheck.FormatNumeric}))
+package idcheck
+
+import (
+	"context"
+	"regexp"
+	"unicode/utf8"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/i18n"
+	"github.com/gaoyong06/go-pkg/middleware/route"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// ID 格式
+const (
+	FormatAny     = ""        // 不限格式
+	FormatUUID    = "uuid"    // UUID，如 "550e8400-e29b-41d4-a716-446655440000"
+	FormatNumeric = "numeric" // 纯数字，如 "10086"
+)
+
+// uuidPattern UUID 格式（不区分大小写）
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Config 身份 ID 校验配置
+type Config struct {
+	// 是否所有请求都必须携带 ID（SkipPaths 除外）
+	Required bool `json:"required" yaml:"required"`
+
+	// Required 为 false 时，只有匹配这些模式的请求必须携带 ID（语法见 route 包）
+	RequirePaths []string `json:"require_paths" yaml:"require_paths"`
+
+	// 不做必填校验的路径或 Kratos operation（语法见 route 包），优先于 Required 和 RequirePaths
+	SkipPaths []string `json:"skip_paths" yaml:"skip_paths"`
+
+	// ID 格式，可选 "uuid"、"numeric"，为空时不限格式；携带了 ID 时始终校验，与是否必填无关
+	Format string `json:"format" yaml:"format"`
+
+	// ID 最小长度（字符数），0 表示不限
+	MinLength int `json:"min_length" yaml:"min_length"`
+
+	// ID 最大长度（字符数），0 表示不限
+	MaxLength int `json:"max_length" yaml:"max_length"`
+}
+
+// Checker 身份 ID 校验器
+type Checker struct {
+	field   string
+	config  Config
+	manager *pkgErrors.ErrorManager
+}
+
+// Option 校验器选项
+type Option func(*Checker)
+
+// WithErrorManager 使用 ErrorManager 生成本地化的错误（默认使用内置中英文文案）
+func WithErrorManager(manager *pkgErrors.ErrorManager) Option {
+	return func(c *Checker) {
+		c.manager = manager
+	}
+}
+
+// New 创建校验器
+// field: 字段名，写入错误的 metadata（"field"），如 "app_id"
+// config: 校验配置，为 nil 时不做任何校验
+func New(field string, config *Config, opts ...Option) *Checker {
+	c := &Checker{field: field}
+	if config != nil {
+		c.config = *config
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Required 当前请求是否必须携带 ID
+func (c *Checker) Required(ctx context.Context) bool {
+	if c == nil {
+		return false
+	}
+	tr, ok := transport.FromServerContext(ctx)
+	if ok && matchRequest(tr, c.config.SkipPaths) {
+		return false
+	}
+	if c.config.Required {
+		return true
+	}
+	return ok && matchRequest(tr, c.config.RequirePaths)
+}
+
+// Valid ID 是否满足格式和长度要求
+func (c *Checker) Valid(id string) bool {
+	if c == nil {
+		return true
+	}
+	length := utf8.RuneCountInString(id)
+	if c.config.MinLength > 0 && length < c.config.MinLength {
+		return false
+	}
+	if c.config.MaxLength > 0 && length > c.config.MaxLength {
+		return false
+	}
+
+	switch c.config.Format {
+	case FormatUUID:
+		return uuidPattern.MatchString(id)
+	case FormatNumeric:
+		if id == "" {
+			return false
+		}
+		for _, r := range id {
+			if r < '0' || r > '9' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Check 校验 ID：必填但缺失时返回 ErrCodeMissingRequiredField，格式错误时返回 ErrCodeInvalidFormat
+func (c *Checker) Check(ctx context.Context, id string) error {
+	if c == nil {
+		return nil
+	}
+	if id == "" {
+		if c.Required(ctx) {
+			return c.newError(ctx, pkgErrors.ErrCodeMissingRequiredField)
+		}
+		return nil
+	}
+	if !c.Valid(id) {
+		return c.newError(ctx, pkgErrors.ErrCodeInvalidFormat)
+	}
+	return nil
+}
+
+// matchRequest 请求是否匹配任意模式：匹配 Kratos operation；HTTP 请求还会按 "方法 + URL 路径" 匹配
+func matchRequest(tr transport.Transporter, patterns []string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	if route.MatchAny("", tr.Operation(), patterns) {
+		return true
+	}
+	if httpTr, ok := tr.(*kratoshttp.Transport); ok && httpTr.Request() != nil {
+		r := httpTr.Request()
+		return route.MatchAny(r.Method, r.URL.Path, patterns)
+	}
+	return false
+}
+
+// checkMessages 未配置 ErrorManager 时使用的内置文案
+var checkMessages = pkgErrors.Messages{
+	"zh-CN": {
+		pkgErrors.ErrCodeMissingRequiredField: "缺少必要的身份信息",
+		pkgErrors.ErrCodeInvalidFormat:        "身份信息格式错误",
+	},
+	"en-US": {
+		pkgErrors.ErrCodeMissingRequiredField: "Missing required identity information",
+		pkgErrors.ErrCodeInvalidFormat:        "Invalid identity format",
+	},
+}
+
+// newError 创建本地化的业务错误，metadata 中包含字段名
+func (c *Checker) newError(ctx context.Context, code int32) *kratosErrors.Error {
+	err := pkgErrors.NewBizErrorWithFallback(ctx, c.manager, checkMessages, i18n.Language(ctx), code)
+	if c.field == "" {
+		return err
+	}
+	return err.WithMetadata(map[string]string{"field": c.field})
+}
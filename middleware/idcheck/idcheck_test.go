@@ -0,0 +1,107 @@
+package idcheck_test
+
+import (
+	"context"
+	"testing"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/app_id"
+	"github.com/gaoyong06/go-pkg/middleware/auth/authtest"
+	"github.com/gaoyong06/go-pkg/middleware/developer_id"
+	"github.com/gaoyong06/go-pkg/middleware/i18n"
+	"github.com/gaoyong06/go-pkg/middleware/idcheck"
+	"github.com/gaoyong06/go-pkg/middleware/user_id"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errorCode 返回业务错误码，err 为 nil 时返回 0
+func errorCode(err error) int32 {
+	if err == nil {
+		return 0
+	}
+	return kratosErrors.FromError(err).Code
+}
+
+func TestCheckerRequired(t *testing.T) {
+	checker := idcheck.New("app_id", &idcheck.Config{
+		RequirePaths: []string{"/api.order.v1.Order/*"},
+		SkipPaths:    []string{"/api.order.v1.Order/Health"},
+	})
+	ctx := func(operation string) context.Context {
+		return authtest.ServerContext(context.Background(), operation, nil)
+	}
+
+	assert.True(t, checker.Required(ctx("/api.order.v1.Order/Get")))
+	assert.False(t, checker.Required(ctx("/api.order.v1.Order/Health")))
+	assert.False(t, checker.Required(ctx("/api.user.v1.User/Get")))
+
+	err := checker.Check(ctx("/api.order.v1.Order/Get"), "")
+	assert.Equal(t, int32(pkgErrors.ErrCodeMissingRequiredField), errorCode(err))
+	assert.Equal(t, "app_id", kratosErrors.FromError(err).Metadata["field"])
+	assert.NoError(t, checker.Check(ctx("/api.user.v1.User/Get"), ""))
+
+	always := idcheck.New("app_id", &idcheck.Config{Required: true, SkipPaths: []string{"/health"}})
+	assert.True(t, always.Required(ctx("/anything")))
+	assert.False(t, always.Required(ctx("/health")))
+	assert.True(t, always.Required(context.Background()))
+}
+
+func TestCheckerFormat(t *testing.T) {
+	cases := []struct {
+		config *idcheck.Config
+		id     string
+		valid  bool
+	}{
+		{&idcheck.Config{Format: idcheck.FormatUUID}, "550e8400-e29b-41d4-a716-446655440000", true},
+		{&idcheck.Config{Format: idcheck.FormatUUID}, "550e8400e29b41d4a716446655440000", false},
+		{&idcheck.Config{Format: idcheck.FormatNumeric}, "10086", true},
+		{&idcheck.Config{Format: idcheck.FormatNumeric}, "10086a", false},
+		{&idcheck.Config{MinLength: 3, MaxLength: 5}, "abcd", true},
+		{&idcheck.Config{MinLength: 3, MaxLength: 5}, "ab", false},
+		{&idcheck.Config{MinLength: 3, MaxLength: 5}, "abcdef", false},
+		{nil, "anything", true},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.valid, idcheck.New("user_id", c.config).Valid(c.id), c.id)
+	}
+
+	// 携带了 ID 时即使不是必填也校验格式，错误文案按语言本地化
+	ctx := i18n.WithLanguage(context.Background(), "en-US")
+	err := idcheck.New("user_id", &idcheck.Config{Format: idcheck.FormatNumeric}).Check(ctx, "abc")
+	assert.Equal(t, int32(pkgErrors.ErrCodeInvalidFormat), errorCode(err))
+	assert.Equal(t, "Invalid identity format", kratosErrors.FromError(err).Message)
+}
+
+func TestIdentityMiddlewaresRequire(t *testing.T) {
+	config := &idcheck.Config{RequirePaths: []string{"/api.order.v1.Order/**"}, Format: idcheck.FormatNumeric}
+	middlewares := map[string]middleware.Middleware{
+		"X-App-Id":       app_id.Middleware(app_id.Require(config)),
+		"X-End-User-Id":  user_id.Middleware(user_id.Require(config)),
+		"X-Developer-Id": developer_id.Middleware(developer_id.Require(config)),
+	}
+	noop := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+
+	for header, m := range middlewares {
+		handler := m(noop)
+		call := func(operation, value string) error {
+			headers := map[string]string{}
+			if value != "" {
+				headers[header] = value
+			}
+			_, err := handler(authtest.ServerContext(context.Background(), operation, headers), nil)
+			return err
+		}
+
+		assert.Equal(t, int32(pkgErrors.ErrCodeMissingRequiredField), errorCode(call("/api.order.v1.Order/Get", "")), header)
+		assert.Equal(t, int32(pkgErrors.ErrCodeInvalidFormat), errorCode(call("/api.order.v1.Order/Get", "abc")), header)
+		require.NoError(t, call("/api.order.v1.Order/Get", "1001"), header)
+		require.NoError(t, call("/health", ""), header)
+	}
+
+	// 不使用 Require 时保持原有行为
+	_, err := app_id.Middleware()(noop)(authtest.ServerContext(context.Background(), "/api.order.v1.Order/Get", nil), nil)
+	assert.NoError(t, err)
+}
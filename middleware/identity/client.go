@@ -0,0 +1,84 @@
+// Package identity 提供统一的调用方身份中间件和工具函数
+package identity
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gaoyong06/go-pkg/middleware/app_id"
+	"github.com/gaoyong06/go-pkg/middleware/developer_id"
+	"github.com/gaoyong06/go-pkg/middleware/i18n"
+	"github.com/gaoyong06/go-pkg/middleware/response"
+	"github.com/gaoyong06/go-pkg/middleware/user_id"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"google.golang.org/grpc/metadata"
+)
+
+// Client 客户端中间件，将 context 中的应用 ID、终端用户 ID、开发者 ID、语言和链路追踪 ID
+// 写入下游请求的请求头（HTTP）或 metadata（gRPC），下游服务的 app_id、user_id、developer_id、
+// i18n、identity 中间件可以直接读取，无需手动调用 metadata.AppendToOutgoingContext
+// 只传播 config.Propagate 白名单中的字段；调用方已经设置的请求头或 outgoing metadata 不会被覆盖
+// 用法：grpc.WithMiddleware(identity.Client(nil))、http.WithMiddleware(identity.Client(nil))
+func Client(config *Config) middleware.Middleware {
+	conf := config.withDefaults()
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			values := conf.outgoingValues(ctx)
+			if len(values) == 0 {
+				return handler(ctx, req)
+			}
+
+			// 调用方通过 metadata.AppendToOutgoingContext 设置的值同样视为已设置，
+			// Kratos gRPC 客户端会合并请求头和 outgoing metadata，重复写入会导致下游收到多个值
+			md, _ := metadata.FromOutgoingContext(ctx)
+
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				header := tr.RequestHeader()
+				for _, kv := range values {
+					if header.Get(kv[0]) == "" && len(md.Get(kv[0])) == 0 {
+						header.Set(kv[0], kv[1])
+					}
+				}
+				return handler(ctx, req)
+			}
+
+			// 没有 Kratos 客户端 transport（如直接使用 grpc-go 客户端），写入 outgoing metadata
+			pairs := make([]string, 0, 2*len(values))
+			for _, kv := range values {
+				key := strings.ToLower(kv[0])
+				if len(md.Get(key)) == 0 {
+					pairs = append(pairs, key, kv[1])
+				}
+			}
+			return handler(metadata.AppendToOutgoingContext(ctx, pairs...), req)
+		}
+	}
+}
+
+// outgoingValues 按白名单收集需要传播的请求头，返回 [请求头, 值] 列表
+func (c *Config) outgoingValues(ctx context.Context) [][2]string {
+	values := make([][2]string, 0, len(c.Propagate))
+	for _, field := range c.Propagate {
+		var header, value string
+		switch field {
+		case FieldAppID:
+			header, value = c.AppIDHeader, app_id.GetAppIDFromContext(ctx)
+		case FieldUserID:
+			header, value = c.UserIDHeader, user_id.GetUserIDFromContext(ctx)
+		case FieldDeveloperID:
+			header, value = c.DeveloperIDHeader, developer_id.GetDeveloperIDFromContext(ctx)
+		case FieldLanguage:
+			// 只传播 i18n 中间件确定的语言，不传播默认语言
+			value, _ = ctx.Value(i18n.LanguageKey).(string)
+			header = c.LanguageHeader
+		case FieldTraceID:
+			header, value = c.TraceIDHeader, response.GetTraceIdFromContext(ctx)
+		}
+		if value != "" {
+			values = append(values, [2]string{header, value})
+		}
+	}
+	return values
+}
@@ -0,0 +1,99 @@
+package identity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gaoyong06/go-pkg/middleware/app_id"
+	"github.com/gaoyong06/go-pkg/middleware/auth/authtest"
+	"github.com/gaoyong06/go-pkg/middleware/developer_id"
+	"github.com/gaoyong06/go-pkg/middleware/i18n"
+	"github.com/gaoyong06/go-pkg/middleware/response"
+	"github.com/gaoyong06/go-pkg/middleware/user_id"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+// identityContext 构造包含全部可传播字段的 context
+func identityContext() context.Context {
+	ctx := app_id.WithAppID(context.Background(), "app-1")
+	ctx = user_id.WithUserID(ctx, "u-1")
+	ctx = developer_id.WithDeveloperID(ctx, "d-1")
+	ctx = i18n.WithLanguage(ctx, "en-US")
+	return response.SetTraceIdToContext(ctx, "trace-1")
+}
+
+// noop 下游 handler，返回收到的 context
+func noop(ctx context.Context, req interface{}) (interface{}, error) {
+	return ctx, nil
+}
+
+func TestClientSetsTransportHeaders(t *testing.T) {
+	ctx, tr := authtest.ClientContext(identityContext(), "/api.order.v1.Order/Get")
+	tr.RequestHeader().Set("X-Trace-Id", "caller-trace")
+
+	_, err := Client(nil)(noop)(ctx, nil)
+	require.NoError(t, err)
+
+	header := tr.RequestHeader()
+	assert.Equal(t, "app-1", header.Get("X-App-Id"))
+	assert.Equal(t, "u-1", header.Get("X-End-User-Id"))
+	assert.Equal(t, "d-1", header.Get("X-Developer-Id"))
+	assert.Equal(t, "en-US", header.Get("Accept-Language"))
+	// 调用方已设置的请求头不被覆盖
+	assert.Equal(t, "caller-trace", header.Get("X-Trace-Id"))
+}
+
+func TestClientAllowlist(t *testing.T) {
+	ctx, tr := authtest.ClientContext(identityContext(), "/op")
+
+	_, err := Client(&Config{Propagate: []string{FieldAppID, FieldTraceID}})(noop)(ctx, nil)
+	require.NoError(t, err)
+
+	header := tr.RequestHeader()
+	assert.Equal(t, "app-1", header.Get("X-App-Id"))
+	assert.Equal(t, "trace-1", header.Get("X-Trace-Id"))
+	assert.Empty(t, header.Get("X-End-User-Id"))
+	assert.Empty(t, header.Get("X-Developer-Id"))
+	assert.Empty(t, header.Get("Accept-Language"))
+}
+
+func TestClientRespectsOutgoingMetadataWithTransport(t *testing.T) {
+	ctx := metadata.AppendToOutgoingContext(identityContext(), "x-app-id", "caller-app")
+	ctx, tr := authtest.ClientContext(ctx, "/api.order.v1.Order/Get")
+
+	_, err := Client(nil)(noop)(ctx, nil)
+	require.NoError(t, err)
+
+	// 调用方已写入 outgoing metadata 的字段不再写入请求头，避免下游收到重复值
+	header := tr.RequestHeader()
+	assert.Empty(t, header.Get("X-App-Id"))
+	assert.Equal(t, "u-1", header.Get("X-End-User-Id"))
+}
+
+func TestClientOutgoingMetadata(t *testing.T) {
+	out, err := Client(nil)(noop)(identityContext(), nil)
+	require.NoError(t, err)
+
+	md, ok := metadata.FromOutgoingContext(out.(context.Context))
+	require.True(t, ok)
+	assert.Equal(t, []string{"app-1"}, md.Get("x-app-id"))
+	assert.Equal(t, []string{"u-1"}, md.Get("x-end-user-id"))
+	assert.Equal(t, []string{"d-1"}, md.Get("x-developer-id"))
+	assert.Equal(t, []string{"trace-1"}, md.Get("x-trace-id"))
+
+	// 下游服务的身份中间件可以直接读取
+	downstream := metadata.NewIncomingContext(context.Background(), md)
+	identity := Extract(downstream, nil)
+	assert.Equal(t, "app-1", identity.AppID)
+	assert.Equal(t, "u-1", identity.UserID)
+	assert.Equal(t, "d-1", identity.DeveloperID)
+}
+
+func TestClientWithoutIdentity(t *testing.T) {
+	out, err := Client(nil)(noop)(context.Background(), nil)
+	require.NoError(t, err)
+	_, ok := metadata.FromOutgoingContext(out.(context.Context))
+	assert.False(t, ok)
+}
@@ -0,0 +1,84 @@
+// Package identity 提供统一的调用方身份中间件和工具函数
+package identity
+
+// Config 身份中间件配置
+type Config struct {
+	// 应用 ID 请求头，默认 "X-App-Id"（由 API Gateway 设置）
+	AppIDHeader string `json:"app_id_header" yaml:"app_id_header"`
+
+	// 应用 ID Query 参数（仅 HTTP，作为后备方案），默认 "appId"；设置为 "-" 时不从 Query 读取
+	AppIDQueryParam string `json:"app_id_query_param" yaml:"app_id_query_param"`
+
+	// 终端用户 ID 请求头，默认 "X-End-User-Id"（由 API Gateway 的 jwt-user 插件设置）
+	UserIDHeader string `json:"user_id_header" yaml:"user_id_header"`
+
+	// 开发者 ID 请求头，默认 "X-Developer-Id"（由 API Gateway 的 api-key 插件设置）
+	DeveloperIDHeader string `json:"developer_id_header" yaml:"developer_id_header"`
+
+	// User-Agent 请求头，默认 "User-Agent"
+	UserAgentHeader string `json:"user_agent_header" yaml:"user_agent_header"`
+
+	// 语言请求头（仅用于 Client 传播），默认 "Accept-Language"
+	LanguageHeader string `json:"language_header" yaml:"language_header"`
+
+	// 链路追踪 ID 请求头（仅用于 Client 传播），默认 "X-Trace-Id"
+	TraceIDHeader string `json:"trace_id_header" yaml:"trace_id_header"`
+
+	// Client 传播到下游的字段白名单，可选 "app_id"、"user_id"、"developer_id"、"language"、"trace_id"，默认全部
+	Propagate []string `json:"propagate" yaml:"propagate"`
+}
+
+// 可传播到下游的字段
+const (
+	FieldAppID       = "app_id"
+	FieldUserID      = "user_id"
+	FieldDeveloperID = "developer_id"
+	FieldLanguage    = "language"
+	FieldTraceID     = "trace_id"
+)
+
+const (
+	defaultAppIDHeader       = "X-App-Id"
+	defaultAppIDQueryParam   = "appId"
+	defaultUserIDHeader      = "X-End-User-Id"
+	defaultDeveloperIDHeader = "X-Developer-Id"
+	defaultUserAgentHeader   = "User-Agent"
+	defaultLanguageHeader    = "Accept-Language"
+	defaultTraceIDHeader     = "X-Trace-Id"
+)
+
+// defaultPropagate 默认传播的字段
+var defaultPropagate = []string{FieldAppID, FieldUserID, FieldDeveloperID, FieldLanguage, FieldTraceID}
+
+// withDefaults 返回填充了默认值的配置副本，config 为 nil 时返回默认配置
+func (c *Config) withDefaults() *Config {
+	conf := Config{}
+	if c != nil {
+		conf = *c
+	}
+	if conf.AppIDHeader == "" {
+		conf.AppIDHeader = defaultAppIDHeader
+	}
+	if conf.AppIDQueryParam == "" {
+		conf.AppIDQueryParam = defaultAppIDQueryParam
+	}
+	if conf.UserIDHeader == "" {
+		conf.UserIDHeader = defaultUserIDHeader
+	}
+	if conf.DeveloperIDHeader == "" {
+		conf.DeveloperIDHeader = defaultDeveloperIDHeader
+	}
+	if conf.UserAgentHeader == "" {
+		conf.UserAgentHeader = defaultUserAgentHeader
+	}
+	if conf.LanguageHeader == "" {
+		conf.LanguageHeader = defaultLanguageHeader
+	}
+	if conf.TraceIDHeader == "" {
+		conf.TraceIDHeader = defaultTraceIDHeader
+	}
+	if len(conf.Propagate) == 0 {
+		conf.Propagate = defaultPropagate
+	}
+	return &conf
+}
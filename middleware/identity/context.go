@@ -0,0 +1,92 @@
+// Package identity 提供统一的调用方身份中间件和工具函数
+package identity
+
+import (
+	"context"
+
+	"github.com/gaoyong06/go-pkg/middleware/app_id"
+	"github.com/gaoyong06/go-pkg/middleware/auth"
+	"github.com/gaoyong06/go-pkg/middleware/developer_id"
+	"github.com/gaoyong06/go-pkg/middleware/user_id"
+)
+
+// identityKey 是 context 中存储调用方身份信息的键
+type identityKey struct{}
+
+// IdentityKey 导出调用方身份键，供外部使用
+var IdentityKey = identityKey{}
+
+// RequestIdentity 调用方身份信息
+type RequestIdentity struct {
+	AppID       string           `json:"app_id,omitempty"`       // 应用 ID
+	UserID      string           `json:"user_id,omitempty"`      // 终端用户 ID（由网关注入）
+	DeveloperID string           `json:"developer_id,omitempty"` // 开发者 ID
+	Claims      *auth.UserClaims `json:"claims,omitempty"`       // 认证后的用户声明，未认证时为 nil
+	ClientIP    string           `json:"client_ip,omitempty"`
+	UserAgent   string           `json:"user_agent,omitempty"`
+}
+
+// Authenticated 是否已通过认证
+func (i *RequestIdentity) Authenticated() bool {
+	return i != nil && i.Claims != nil && i.Claims.UserID != ""
+}
+
+// SubjectID 返回调用方主体 ID：优先认证后的用户 ID，其次网关注入的终端用户 ID，最后为开发者 ID
+func (i *RequestIdentity) SubjectID() string {
+	if i == nil {
+		return ""
+	}
+	if i.Authenticated() {
+		return i.Claims.UserID
+	}
+	if i.UserID != "" {
+		return i.UserID
+	}
+	return i.DeveloperID
+}
+
+// WithIdentity 将调用方身份存入 context，同时写入 app_id、user_id、developer_id 的 context，
+// 使已有的 GetXxxFromContext 方法继续可用
+func WithIdentity(ctx context.Context, identity *RequestIdentity) context.Context {
+	if identity == nil {
+		return ctx
+	}
+	if identity.AppID != "" {
+		ctx = app_id.WithAppID(ctx, identity.AppID)
+	}
+	if identity.UserID != "" {
+		ctx = user_id.WithUserID(ctx, identity.UserID)
+	}
+	if identity.DeveloperID != "" {
+		ctx = developer_id.WithDeveloperID(ctx, identity.DeveloperID)
+	}
+	if identity.Claims != nil {
+		ctx = auth.WithUserClaims(ctx, identity.Claims)
+	}
+	return context.WithValue(ctx, IdentityKey, identity)
+}
+
+// FromContext 获取调用方身份
+// 返回副本，中间件未写入的字段（如 auth 中间件在本中间件之后写入的声明，
+// 或单独使用 app_id 等中间件写入的 ID）从对应的 context 中补全；
+// context 中没有任何身份信息时返回空的 RequestIdentity，不会返回 nil
+func FromContext(ctx context.Context) *RequestIdentity {
+	identity := RequestIdentity{}
+	if stored, ok := ctx.Value(IdentityKey).(*RequestIdentity); ok && stored != nil {
+		identity = *stored
+	}
+
+	if id := app_id.GetAppIDFromContext(ctx); id != "" {
+		identity.AppID = id
+	}
+	if id := developer_id.GetDeveloperIDFromContext(ctx); id != "" {
+		identity.DeveloperID = id
+	}
+	if identity.UserID == "" {
+		identity.UserID = user_id.GetUserIDFromContext(ctx)
+	}
+	if claims, ok := auth.GetUserClaimsFromContext(ctx); ok && claims != nil {
+		identity.Claims = claims
+	}
+	return &identity
+}
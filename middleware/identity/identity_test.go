@@ -0,0 +1,105 @@
+package identity
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/gaoyong06/go-pkg/middleware/app_id"
+	"github.com/gaoyong06/go-pkg/middleware/auth"
+	"github.com/gaoyong06/go-pkg/middleware/auth/authtest"
+	"github.com/gaoyong06/go-pkg/middleware/developer_id"
+	"github.com/gaoyong06/go-pkg/middleware/user_id"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// runMiddleware 执行中间件并返回 handler 收到的 context
+func runMiddleware(t *testing.T, ctx context.Context, config *Config) context.Context {
+	t.Helper()
+	var got context.Context
+	_, err := Middleware(config, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		got = ctx
+		return nil, nil
+	})(ctx, nil)
+	require.NoError(t, err)
+	return got
+}
+
+func TestMiddlewareExtractsAllIdentity(t *testing.T) {
+	ctx := authtest.ServerContext(context.Background(), "/api.order.v1.Order/Get", map[string]string{
+		"X-App-Id":        " app-1 ",
+		"X-End-User-Id":   "u-1",
+		"X-Developer-Id":  "d-1",
+		"X-Forwarded-For": "203.0.113.7, 10.0.0.1",
+		"User-Agent":      "curl/8.0",
+	})
+	ctx = auth.WithUserClaims(ctx, &auth.UserClaims{UserID: "u-1", Role: "admin"})
+
+	got := runMiddleware(t, ctx, nil)
+	identity := FromContext(got)
+	assert.Equal(t, "app-1", identity.AppID)
+	assert.Equal(t, "u-1", identity.UserID)
+	assert.Equal(t, "d-1", identity.DeveloperID)
+	assert.Equal(t, "203.0.113.7", identity.ClientIP)
+	assert.Equal(t, "curl/8.0", identity.UserAgent)
+	assert.True(t, identity.Authenticated())
+	assert.Equal(t, "u-1", identity.SubjectID())
+
+	// 已有的 getter 继续可用
+	assert.Equal(t, "app-1", app_id.GetAppIDFromContext(got))
+	assert.Equal(t, "u-1", user_id.GetUserIDFromContext(got))
+	assert.Equal(t, "d-1", developer_id.GetDeveloperIDFromContext(got))
+}
+
+func TestMiddlewareCustomHeaders(t *testing.T) {
+	ctx := authtest.ServerContext(context.Background(), "/op", map[string]string{
+		"X-App-Id":    "ignored",
+		"X-Tenant":    "tenant-9",
+		"X-Caller-Id": "u-9",
+	})
+
+	identity := FromContext(runMiddleware(t, ctx, &Config{AppIDHeader: "X-Tenant", UserIDHeader: "X-Caller-Id"}))
+	assert.Equal(t, "tenant-9", identity.AppID)
+	assert.Equal(t, "u-9", identity.UserID)
+	assert.False(t, identity.Authenticated())
+	assert.Equal(t, "u-9", identity.SubjectID())
+}
+
+func TestExtractFromGRPCMetadata(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"x-app-id", "app-2",
+		"x-developer-id", "d-2",
+	))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 5000}})
+
+	identity := Extract(ctx, nil)
+	assert.Equal(t, "app-2", identity.AppID)
+	assert.Equal(t, "d-2", identity.DeveloperID)
+	assert.Equal(t, "10.1.2.3", identity.ClientIP)
+	assert.Equal(t, "d-2", identity.SubjectID())
+}
+
+func TestFromContextPicksUpLaterMiddlewares(t *testing.T) {
+	ctx := runMiddleware(t, authtest.ServerContext(context.Background(), "/op", map[string]string{"X-App-Id": "app-1"}), nil)
+
+	// auth / api key 中间件在身份中间件之后写入
+	ctx = auth.WithUserClaims(ctx, &auth.UserClaims{UserID: "u-3"})
+	ctx = developer_id.WithDeveloperID(ctx, "d-3")
+
+	identity := FromContext(ctx)
+	assert.Equal(t, "app-1", identity.AppID)
+	assert.Equal(t, "d-3", identity.DeveloperID)
+	assert.Equal(t, "u-3", identity.SubjectID())
+}
+
+func TestFromContextEmpty(t *testing.T) {
+	identity := FromContext(context.Background())
+	require.NotNil(t, identity)
+	assert.Empty(t, identity.AppID)
+	assert.False(t, identity.Authenticated())
+	assert.Empty(t, identity.SubjectID())
+}
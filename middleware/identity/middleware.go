@@ -0,0 +1,116 @@
+// Package identity 提供统一的调用方身份中间件和工具函数
+package identity
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/gaoyong06/go-pkg/middleware/auth"
+	"github.com/gaoyong06/go-pkg/utils"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Middleware 调用方身份中间件，一次性提取应用 ID、终端用户 ID、开发者 ID、认证声明、客户端 IP 和 User-Agent
+// 可以替代依次使用 app_id、user_id、developer_id 三个中间件；提取结果同时写入这三个包的 context，
+// 已有的 GetXxxFromContext 方法继续可用
+// 认证声明取自 auth 中间件，auth 中间件放在本中间件之后时，通过 FromContext 读取也能拿到
+// 请求头提取优先级与原中间件一致：Kratos 请求头 -> Query 参数（仅应用 ID）-> gRPC metadata
+// 需要防止伪造时，在本中间件之前使用 trust.Middleware 删除不可信来源的身份请求头
+func Middleware(config *Config, logger log.Logger) middleware.Middleware {
+	conf := config.withDefaults()
+	logHelper := log.NewHelper(logger)
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			identity := Extract(ctx, conf)
+			logHelper.WithContext(ctx).Debugf("identity middleware: app_id=%s developer_id=%s authenticated=%v",
+				identity.AppID, identity.DeveloperID, identity.Authenticated())
+			return handler(WithIdentity(ctx, identity), req)
+		}
+	}
+}
+
+// Extract 从请求中提取调用方身份，config 为 nil 时使用默认配置
+func Extract(ctx context.Context, config *Config) *RequestIdentity {
+	conf := config.withDefaults()
+	identity := &RequestIdentity{}
+
+	tr, hasTransport := transport.FromServerContext(ctx)
+	if hasTransport {
+		header := tr.RequestHeader()
+		identity.AppID = headerValue(header, conf.AppIDHeader)
+		identity.UserID = headerValue(header, conf.UserIDHeader)
+		identity.DeveloperID = headerValue(header, conf.DeveloperIDHeader)
+		identity.UserAgent = headerValue(header, conf.UserAgentHeader)
+		identity.ClientIP = utils.GetClientIPRaw(ctx)
+
+		if identity.AppID == "" && conf.AppIDQueryParam != "-" {
+			if httpTr, ok := tr.(*kratoshttp.Transport); ok {
+				if r := httpTr.Request(); r != nil && r.URL != nil {
+					identity.AppID = strings.TrimSpace(r.URL.Query().Get(conf.AppIDQueryParam))
+				}
+			}
+		}
+	}
+
+	// 服务间调用或没有 transport 时，从 gRPC metadata 补全
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if identity.AppID == "" {
+			identity.AppID = metadataValue(md, conf.AppIDHeader)
+		}
+		if identity.UserID == "" {
+			identity.UserID = metadataValue(md, conf.UserIDHeader)
+		}
+		if identity.DeveloperID == "" {
+			identity.DeveloperID = metadataValue(md, conf.DeveloperIDHeader)
+		}
+		if identity.UserAgent == "" {
+			identity.UserAgent = metadataValue(md, conf.UserAgentHeader)
+		}
+	}
+
+	if identity.ClientIP == "" {
+		identity.ClientIP = peerIP(ctx)
+	}
+
+	if claims, ok := auth.GetUserClaimsFromContext(ctx); ok {
+		identity.Claims = claims
+	}
+	return identity
+}
+
+// headerValue 读取请求头，兼容小写 key
+func headerValue(header transport.Header, key string) string {
+	if value := header.Get(key); value != "" {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(header.Get(strings.ToLower(key)))
+}
+
+// metadataValue 读取 gRPC metadata（key 不区分大小写）
+func metadataValue(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// peerIP 从 gRPC peer 获取直连 IP
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
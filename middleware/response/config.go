@@ -1,13 +1,15 @@
 // Package response 提供统一响应格式中间件
 package response
 
+import "github.com/gaoyong06/go-pkg/middleware/route"
+
 // Config 响应格式配置
 // 注意：不包含业务相关的默认值，需要在项目中自定义
 type Config struct {
 	// 是否启用统一响应格式
 	EnableUnifiedResponse bool `json:"enable_unified_response" yaml:"enable_unified_response"`
 
-	// 跳过统一响应格式的路径（支持通配符）
+	// 跳过统一响应格式的路径或 Kratos operation（支持通配符、路径参数、正则和 HTTP 方法限定，语法见 route 包）
 	// 注意：需要在项目中自定义，不提供默认值
 	SkipPaths []string `json:"skip_paths" yaml:"skip_paths"`
 
@@ -20,22 +22,45 @@ type Config struct {
 	// 是否在响应中包含 TraceId
 	IncludeTraceId bool `json:"include_trace_id" yaml:"include_trace_id"`
 
-	// 自定义 TraceId 头部名称
+	// 自定义 TraceId 头部名称，默认 "X-Trace-Id"
+	// 请求携带该请求头时沿用其中的 TraceId，响应中通过同名响应头返回
 	TraceIdHeader string `json:"trace_id_header" yaml:"trace_id_header"`
+
+	// 错误响应使用 RFC 7807 格式（application/problem+json）的路径或 Kratos operation，语法同 SkipPaths
+	// 需要配合 NewErrorEncoder(handler, WithProblemDetails(config)) 使用
+	ProblemDetailsPaths []string `json:"problem_details_paths" yaml:"problem_details_paths"`
+
+	// RFC 7807 问题类型 URI 前缀，type 为前缀 + 错误码，默认 "/problems/"
+	ProblemTypeBaseURI string `json:"problem_type_base_uri" yaml:"problem_type_base_uri"`
+}
+
+// traceIdHeader 返回 TraceId 头部名称，未配置时使用 DefaultTraceIdHeader
+func (c *Config) traceIdHeader() string {
+	if c == nil || c.TraceIdHeader == "" {
+		return DefaultTraceIdHeader
+	}
+	return c.TraceIdHeader
+}
+
+// UseProblemDetails 判断请求的错误响应是否使用 RFC 7807 格式
+func (c *Config) UseProblemDetails(method, path string) bool {
+	if c == nil {
+		return false
+	}
+	return route.MatchAny(method, path, c.ProblemDetailsPaths)
 }
 
 // ShouldSkipPath 判断是否应该跳过某个路径
 func (c *Config) ShouldSkipPath(path string) bool {
+	return c.ShouldSkip("", path)
+}
+
+// ShouldSkip 判断是否应该跳过某个请求（HTTP 方法 + 路径或 Kratos operation）
+// method 为空时，限定了 HTTP 方法的模式不匹配
+func (c *Config) ShouldSkip(method, path string) bool {
 	if !c.EnableUnifiedResponse {
 		return true
 	}
-
-	for _, skipPath := range c.SkipPaths {
-		if MatchPath(path, skipPath) {
-			return true
-		}
-	}
-
-	return false
+	return route.MatchAny(method, path, c.SkipPaths)
 }
 
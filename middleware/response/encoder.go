@@ -5,17 +5,50 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/gaoyong06/go-pkg/middleware/i18n"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
 
+// EncoderOption 响应编码器的可选参数
+type EncoderOption func(*encoderOptions)
+
+// encoderOptions 响应编码器的可选配置
+type encoderOptions struct {
+	protoLocalizer *i18n.ProtoLocalizer
+	traceIdHeader  string
+	problemConfig  *Config
+}
+
+// WithTraceIdHeader 设置携带 TraceId 的请求头和响应头名称，需要与中间件 Config.TraceIdHeader 一致
+// 未设置时使用 config.TraceIdHeader（NewResponseEncoder、NewProblemErrorEncoder 的 config 或 WithProblemDetails 的 config），默认 "X-Trace-Id"
+func WithTraceIdHeader(header string) EncoderOption {
+	return func(o *encoderOptions) {
+		o.traceIdHeader = header
+	}
+}
+
+// WithProtoLocalizer 编码 proto 响应前，按请求语言填充枚举值对应的展示字段
+func WithProtoLocalizer(localizer *i18n.ProtoLocalizer) EncoderOption {
+	return func(o *encoderOptions) {
+		o.protoLocalizer = localizer
+	}
+}
+
 // NewResponseEncoder 创建响应编码器
 // errorHandler: 错误处理接口，如果为 nil，使用默认处理
 // config: 配置信息，如果为 nil，不跳过任何路径
-func NewResponseEncoder(errorHandler ErrorHandler, config *Config) func(http.ResponseWriter, *http.Request, interface{}) error {
+// opts: 可选参数，如 WithProtoLocalizer
+// TraceId 与中间件写入响应头的 TraceId 保持一致，TraceId 头部名称默认取 config.TraceIdHeader
+func NewResponseEncoder(errorHandler ErrorHandler, config *Config, opts ...EncoderOption) func(http.ResponseWriter, *http.Request, interface{}) error {
+	options := &encoderOptions{traceIdHeader: config.traceIdHeader()}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	return func(w http.ResponseWriter, r *http.Request, v interface{}) error {
 		// 检查是否应该跳过统一响应格式
-		if config != nil && config.ShouldSkipPath(r.URL.Path) {
+		if config != nil && config.ShouldSkip(r.Method, r.URL.Path) {
 			// 跳过统一响应格式，直接返回原始响应
 			// 注意：这里需要确保响应已经被正确设置
 			return nil
@@ -31,7 +64,7 @@ func NewResponseEncoder(errorHandler ErrorHandler, config *Config) func(http.Res
 
 		// 如果 v 为 nil（服务返回 nil, nil），返回 data 为 null 的响应
 		if v == nil {
-			traceId := GenerateUUID()
+			traceId := options.traceId(w, r)
 			host := r.Host
 			response := &ResponseStructure{
 				Success:      true,
@@ -52,6 +85,7 @@ func NewResponseEncoder(errorHandler ErrorHandler, config *Config) func(http.Res
 
 			// 对于protobuf消息，使用protojson序列化以处理零值字段
 			if msg, ok := resp.Data.(proto.Message); ok {
+				options.localize(r, msg)
 				jsonBytes, err := protojson.MarshalOptions{
 					EmitUnpopulated: true,  // 包含零值字段
 					UseProtoNames:   false, // 使用JSON字段名（驼峰命名）
@@ -75,8 +109,9 @@ func NewResponseEncoder(errorHandler ErrorHandler, config *Config) func(http.Res
 
 		// 如果是protobuf消息，包装为ResponseStructure
 		if msg, ok := v.(proto.Message); ok {
-			traceId := GenerateUUID()
+			traceId := options.traceId(w, r)
 			host := r.Host
+			options.localize(r, msg)
 
 			// 使用protojson序列化以处理零值字段
 			jsonBytes, err := protojson.MarshalOptions{
@@ -107,7 +142,7 @@ func NewResponseEncoder(errorHandler ErrorHandler, config *Config) func(http.Res
 		}
 
 		// 其他情况，包装为ResponseStructure
-		traceId := GenerateUUID()
+		traceId := options.traceId(w, r)
 		host := r.Host
 
 		response := &ResponseStructure{
@@ -124,14 +159,65 @@ func NewResponseEncoder(errorHandler ErrorHandler, config *Config) func(http.Res
 	}
 }
 
+// localize 按请求语言本地化 proto 消息（未配置本地化器时不处理）
+func (o *encoderOptions) localize(r *http.Request, msg proto.Message) {
+	if o.protoLocalizer == nil {
+		return
+	}
+	ctx := i18n.WithLanguage(r.Context(), i18n.RequestLanguage(r.Context()))
+	o.protoLocalizer.Localize(ctx, msg)
+}
+
+// traceId 获取本次请求的 TraceId 并写入响应头
+// 优先使用中间件已写入响应头的 TraceId；中间件未执行时（如路由不存在、请求解码失败）
+// 从请求 context 和请求头获取，都没有时生成新的 TraceId
+func (o *encoderOptions) traceId(w http.ResponseWriter, r *http.Request) string {
+	header := o.traceIdHeader
+	if header == "" {
+		header = DefaultTraceIdHeader
+	}
+	if id := w.Header().Get(header); id != "" {
+		return id
+	}
+
+	// 与中间件使用相同的优先级（显式设置、OpenTelemetry、请求头），请求 context 中没有 transport 时再读取请求头
+	id := traceIdFromContext(r.Context(), header)
+	if id == "" {
+		id = traceIdFromHeaders(r.Header.Get, header)
+	}
+	if id == "" {
+		id = GenerateTraceId()
+	}
+	w.Header().Set(header, id)
+	return id
+}
+
 // NewErrorEncoder 创建错误编码器
 // errorHandler: 错误处理接口，必须提供
-func NewErrorEncoder(errorHandler ErrorHandler) func(http.ResponseWriter, *http.Request, error) {
+// opts: 可选参数，如 WithTraceIdHeader、WithProblemDetails
+// TraceId 头部名称优先取 WithTraceIdHeader，其次取 WithProblemDetails 传入的 config.TraceIdHeader，默认 "X-Trace-Id"
+func NewErrorEncoder(errorHandler ErrorHandler, opts ...EncoderOption) func(http.ResponseWriter, *http.Request, error) {
 	if errorHandler == nil {
 		panic("ErrorHandler cannot be nil")
 	}
+	options := &encoderOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.traceIdHeader == "" {
+		options.traceIdHeader = options.problemConfig.traceIdHeader()
+	}
 
 	return func(w http.ResponseWriter, r *http.Request, err error) {
+		// 响应头必须在 WriteHeader 之前设置
+		traceId := options.traceId(w, r)
+
+		// 配置为 RFC 7807 格式的路由
+		if options.problemConfig.useProblemDetailsFor(r) {
+			writeProblem(w, NewProblem(errorHandler, options.problemConfig, err, r, traceId))
+			return
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 
 		// 获取HTTP状态码
@@ -139,7 +225,6 @@ func NewErrorEncoder(errorHandler ErrorHandler) func(http.ResponseWriter, *http.
 		w.WriteHeader(statusCode)
 
 		// 生成错误响应
-		traceId := GenerateUUID()
 		host := r.Host
 
 		response := &ResponseStructure{
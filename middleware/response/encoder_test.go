@@ -0,0 +1,69 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestErrorEncoderTraceId(t *testing.T) {
+	encode := NewErrorEncoder(NewDefaultErrorHandler())
+	bizErr := kratosErrors.New(100301, "BIZ_ERROR", "not found")
+
+	// 沿用中间件已写入响应头的 TraceId
+	w := httptest.NewRecorder()
+	w.Header().Set(DefaultTraceIdHeader, "from-middleware")
+	encode(w, httptest.NewRequest(http.MethodGet, "/v1/orders/1", nil), bizErr)
+
+	var resp ResponseStructure
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "from-middleware", resp.TraceId)
+	assert.Equal(t, "from-middleware", w.Result().Header.Get(DefaultTraceIdHeader))
+
+	// 中间件未执行时沿用请求头中的 TraceId
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/orders/1", nil)
+	r.Header.Set(DefaultTraceIdHeader, "from-gateway")
+	encode(w, r, bizErr)
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "from-gateway", resp.TraceId)
+	assert.Equal(t, "from-gateway", w.Result().Header.Get(DefaultTraceIdHeader))
+
+	// 与中间件一致，OpenTelemetry span 的 TraceId 优先于请求头
+	const otelID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceID, err := trace.TraceIDFromHex(otelID)
+	require.NoError(t, err)
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1}})
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/v1/orders/1", nil)
+	r = r.WithContext(trace.ContextWithSpanContext(r.Context(), spanCtx))
+	r.Header.Set(DefaultTraceIdHeader, "from-gateway")
+	encode(w, r, bizErr)
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, otelID, resp.TraceId)
+	assert.Equal(t, otelID, w.Result().Header.Get(DefaultTraceIdHeader))
+}
+
+func TestResponseEncoderTraceId(t *testing.T) {
+	config := &Config{EnableUnifiedResponse: true, TraceIdHeader: "X-Request-Id"}
+	encode := NewResponseEncoder(NewDefaultErrorHandler(), config)
+
+	// 请求头中的 TraceId 不合法时生成新的 TraceId，响应体和响应头一致
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/orders/1", nil)
+	r.Header.Set("X-Request-Id", "bad id\n")
+	require.NoError(t, encode(w, r, map[string]string{"id": "1"}))
+
+	var resp ResponseStructure
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Regexp(t, `^[0-9a-f]{32}$`, resp.TraceId)
+	assert.Equal(t, resp.TraceId, w.Result().Header.Get("X-Request-Id"))
+}
@@ -3,16 +3,35 @@ package response
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// gRPC 响应 metadata 的键
+const (
+	// TraceIdMetadataKey 链路追踪 ID，写入 gRPC 响应 header
+	TraceIdMetadataKey = "x-trace-id"
+	// ErrorCodeMetadataKey 业务错误码，出错时写入 gRPC 响应 trailer
+	ErrorCodeMetadataKey = "x-error-code"
+	// ShowTypeMetadataKey 错误展示类型，出错时写入 gRPC 响应 trailer
+	ShowTypeMetadataKey = "x-show-type"
 )
 
 // Middleware 统一响应格式中间件
 // config: 配置信息
 // errorHandler: 错误处理接口
 // logger: 日志记录器
+// 每个请求确定唯一的 trace ID（沿用 OpenTelemetry、TraceIdHeader 请求头或 traceparent 中的 ID，否则生成），
+// 写入 context 和响应头（HTTP 为 TraceIdHeader，gRPC 为 x-trace-id），编码器和日志读取同一个 ID
+// 只对 HTTP 请求包装为 ResponseStructure；gRPC 请求保持原始的 proto 响应和错误，
+// 出错时错误码和展示类型写入响应 trailer（x-error-code、x-show-type），不受 EnableUnifiedResponse 和 SkipPaths 影响
+// 匹配 Config.ProblemDetailsPaths 的 HTTP 请求出错时原样返回错误，由错误编码器输出 RFC 7807 格式
 func Middleware(config *Config, errorHandler ErrorHandler, logger log.Logger) middleware.Middleware {
 	logHelper := log.NewHelper(logger)
 
@@ -22,28 +41,45 @@ func Middleware(config *Config, errorHandler ErrorHandler, logger log.Logger) mi
 			tr, ok := transport.FromServerContext(ctx)
 			if !ok {
 				logHelper.Debug("无法获取传输信息")
+				return handler(ctx, req)
+			}
+
+			// 获取或生成本次请求的 trace ID，写入 context 和响应头，编码器和日志使用同一个 ID
+			traceId := traceIdFromContext(ctx, config.traceIdHeader())
+			if traceId == "" {
+				traceId = GenerateTraceId()
+			}
+			ctx = SetTraceIdToContext(ctx, traceId)
+			if tr.Kind() == transport.KindHTTP {
+				tr.ReplyHeader().Set(config.traceIdHeader(), traceId)
+			} else {
+				tr.ReplyHeader().Set(TraceIdMetadataKey, traceId)
+			}
+
+			// gRPC 请求不包装响应，无论是否启用统一响应格式或匹配 SkipPaths，出错时都写入 trailer
+			if tr.Kind() != transport.KindHTTP {
+				reply, err = handler(ctx, req)
+				return reply, annotateGRPC(ctx, errorHandler, traceId, err, logHelper)
 			}
 
 			// 检查是否应该跳过统一响应格式
-			if tr != nil {
-				operation := tr.Operation()
-				if config.ShouldSkipPath(operation) {
-					// 跳过统一响应格式，直接返回原始响应
-					return handler(ctx, req)
-				}
+			if shouldSkip(config, tr) {
+				// 跳过统一响应格式，直接返回原始响应
+				return handler(ctx, req)
 			}
 
 			// 执行业务逻辑
 			reply, err = handler(ctx, req)
 
-			// 生成或获取 trace ID
-			traceId := ""
-			if config.IncludeTraceId {
-				traceId = GetTraceIdFromContext(ctx)
-				if traceId == "" {
-					traceId = GenerateUUID()
-					ctx = SetTraceIdToContext(ctx, traceId)
-				}
+			// RFC 7807 格式的路由原样返回错误，由错误编码器输出 application/problem+json
+			if err != nil && useProblemDetails(config, tr) {
+				logHelper.WithContext(ctx).Errorf("API错误: %v, TraceId: %s", err, traceId)
+				return nil, err
+			}
+
+			// 是否在响应体中包含 trace ID
+			if !config.IncludeTraceId {
+				traceId = ""
 			}
 
 			// 获取主机信息
@@ -66,7 +102,7 @@ func Middleware(config *Config, errorHandler ErrorHandler, logger log.Logger) mi
 					Host:         host,
 				}
 
-				logHelper.Errorf("API错误: %v, TraceId: %s", err, traceId)
+				logHelper.WithContext(ctx).Errorf("API错误: %v, TraceId: %s", err, GetTraceIdFromContext(ctx))
 				return errorResponse, nil // 返回nil错误，让框架正常处理响应
 			}
 
@@ -86,3 +122,49 @@ func Middleware(config *Config, errorHandler ErrorHandler, logger log.Logger) mi
 	}
 }
 
+// annotateGRPC 将错误码和展示类型写入 gRPC 响应 trailer，原样返回错误
+// trace ID 已由中间件通过 transport 的 ReplyHeader 写入响应 header（由 Kratos 发送）；
+// 错误码和展示类型通过 grpc.SetTrailer 写入 trailer，客户端使用 grpc.Trailer 调用选项读取
+func annotateGRPC(ctx context.Context, errorHandler ErrorHandler, traceId string, err error, logHelper *log.Helper) error {
+	if err == nil {
+		return nil
+	}
+
+	trailer := metadata.Pairs(
+		ErrorCodeMetadataKey, errorHandler.GetErrorCode(err),
+		ShowTypeMetadataKey, strconv.Itoa(errorHandler.GetErrorShowType(err)),
+		TraceIdMetadataKey, traceId,
+	)
+	if setErr := grpc.SetTrailer(ctx, trailer); setErr != nil {
+		logHelper.Debugf("设置 gRPC trailer 失败: %v", setErr)
+	}
+
+	logHelper.WithContext(ctx).Errorf("API错误: %v, TraceId: %s", err, traceId)
+	return err
+}
+
+// useProblemDetails 判断 HTTP 请求的错误响应是否使用 RFC 7807 格式（匹配请求路径或 Kratos operation）
+func useProblemDetails(config *Config, tr transport.Transporter) bool {
+	method, path := httpTarget(tr)
+	if path != "" && config.UseProblemDetails(method, path) {
+		return true
+	}
+	return config.UseProblemDetails("", tr.Operation())
+}
+
+// shouldSkip 判断请求是否跳过统一响应格式，与编码器一致：HTTP 请求先按方法 + URL 路径匹配，再按 operation 匹配
+func shouldSkip(config *Config, tr transport.Transporter) bool {
+	method, path := httpTarget(tr)
+	if path != "" && config.ShouldSkip(method, path) {
+		return true
+	}
+	return config.ShouldSkip("", tr.Operation())
+}
+
+// httpTarget 返回 HTTP 请求的方法和 URL 路径，非 HTTP 请求返回空字符串
+func httpTarget(tr transport.Transporter) (method, path string) {
+	if httpTr, ok := tr.(*kratoshttp.Transport); ok && httpTr.Request() != nil {
+		return httpTr.Request().Method, httpTr.Request().URL.Path
+	}
+	return "", ""
+}
@@ -0,0 +1,178 @@
+package response
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gaoyong06/go-pkg/middleware/auth/authtest"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// fakeStream 记录 trailer 的 grpc.ServerTransportStream
+type fakeStream struct {
+	trailer metadata.MD
+}
+
+func (s *fakeStream) Method() string                  { return "/api.order.v1.Order/Get" }
+func (s *fakeStream) SetHeader(md metadata.MD) error  { return nil }
+func (s *fakeStream) SendHeader(md metadata.MD) error { return nil }
+func (s *fakeStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+// testConfig 启用统一响应格式和 trace ID 的配置
+var testConfig = &Config{EnableUnifiedResponse: true, IncludeTraceId: true}
+
+func TestMiddlewareGRPCSuccess(t *testing.T) {
+	tr := authtest.NewTransport(transport.KindGRPC, "/api.order.v1.Order/Get", nil)
+	ctx := transport.NewServerContext(context.Background(), tr)
+
+	type orderReply struct{ ID string }
+	reply, err := Middleware(testConfig, NewDefaultErrorHandler(), log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		assert.NotEmpty(t, GetTraceIdFromContext(ctx))
+		return &orderReply{ID: "1"}, nil
+	})(ctx, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, &orderReply{ID: "1"}, reply, "gRPC reply must keep the proto type")
+	assert.NotEmpty(t, tr.ReplyHeader().Get(TraceIdMetadataKey))
+}
+
+func TestMiddlewareGRPCError(t *testing.T) {
+	tr := authtest.NewTransport(transport.KindGRPC, "/api.order.v1.Order/Get", nil)
+	stream := &fakeStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	ctx = SetTraceIdToContext(transport.NewServerContext(ctx, tr), "trace-1")
+
+	bizErr := kratosErrors.New(100301, "BIZ_ERROR", "not found")
+	handler := NewDefaultErrorHandler(WithStatusMapping(map[int]int{100301: 404}))
+	reply, err := Middleware(testConfig, handler, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, bizErr
+	})(ctx, nil)
+
+	assert.Nil(t, reply)
+	assert.Equal(t, bizErr, err, "gRPC errors are returned unchanged")
+	assert.Equal(t, "trace-1", tr.ReplyHeader().Get(TraceIdMetadataKey))
+	assert.Equal(t, []string{"100301"}, stream.trailer.Get(ErrorCodeMetadataKey))
+	assert.Equal(t, []string{"1"}, stream.trailer.Get(ShowTypeMetadataKey))
+	assert.Equal(t, []string{"trace-1"}, stream.trailer.Get(TraceIdMetadataKey))
+}
+
+func TestMiddlewareGRPCErrorSkipped(t *testing.T) {
+	configs := map[string]*Config{
+		"disabled":  {},
+		"skip path": {EnableUnifiedResponse: true, SkipPaths: []string{"/api.order.v1.Order/*"}},
+	}
+	for name, config := range configs {
+		tr := authtest.NewTransport(transport.KindGRPC, "/api.order.v1.Order/Get", nil)
+		stream := &fakeStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		ctx = transport.NewServerContext(ctx, tr)
+
+		// 未启用统一响应格式或匹配 SkipPaths 的 gRPC 请求同样写入 trailer
+		_, err := Middleware(config, NewDefaultErrorHandler(), log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, kratosErrors.New(100301, "BIZ_ERROR", "not found")
+		})(ctx, nil)
+		require.Error(t, err, name)
+		assert.Equal(t, []string{"100301"}, stream.trailer.Get(ErrorCodeMetadataKey), name)
+		assert.Equal(t, []string{tr.ReplyHeader().Get(TraceIdMetadataKey)}, stream.trailer.Get(TraceIdMetadataKey), name)
+	}
+}
+
+func TestMiddlewareHTTPEnvelope(t *testing.T) {
+	tr := authtest.NewTransport(transport.KindHTTP, "/api.order.v1.Order/Get", nil)
+	ctx := transport.NewServerContext(context.Background(), tr)
+
+	reply, err := Middleware(testConfig, NewDefaultErrorHandler(), log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, kratosErrors.New(100301, "BIZ_ERROR", "not found")
+	})(ctx, nil)
+
+	require.NoError(t, err)
+	resp, ok := reply.(*ResponseStructure)
+	require.True(t, ok)
+	assert.False(t, resp.Success)
+	assert.Equal(t, "100301", resp.ErrorCode)
+	assert.NotEmpty(t, resp.TraceId)
+	assert.Equal(t, resp.TraceId, tr.ReplyHeader().Get(DefaultTraceIdHeader))
+}
+
+func TestMiddlewareUsesTraceparent(t *testing.T) {
+	tr := authtest.NewTransport(transport.KindHTTP, "/api.order.v1.Order/Get", map[string]string{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+	ctx := transport.NewServerContext(context.Background(), tr)
+
+	reply, err := Middleware(testConfig, NewDefaultErrorHandler(), log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})(ctx, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", reply.(*ResponseStructure).TraceId)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tr.ReplyHeader().Get(DefaultTraceIdHeader))
+}
+
+func TestMiddlewareCustomTraceIdHeader(t *testing.T) {
+	config := &Config{EnableUnifiedResponse: true, IncludeTraceId: true, TraceIdHeader: "X-Request-Id"}
+	tr := authtest.NewTransport(transport.KindHTTP, "/api.order.v1.Order/Get", map[string]string{
+		"X-Request-Id": "gateway-1",
+	})
+	ctx := transport.NewServerContext(context.Background(), tr)
+
+	reply, err := Middleware(config, NewDefaultErrorHandler(), log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		assert.Equal(t, "gateway-1", GetTraceIdFromContext(ctx))
+		return nil, nil
+	})(ctx, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "gateway-1", reply.(*ResponseStructure).TraceId)
+	assert.Equal(t, "gateway-1", tr.ReplyHeader().Get("X-Request-Id"))
+}
+
+func TestMiddlewareSkipPathsMethodQualified(t *testing.T) {
+	config := &Config{EnableUnifiedResponse: true, SkipPaths: []string{"GET /v1/files/*"}}
+
+	// 记录中间件返回的响应类型，不经过响应编码器
+	srv := kratoshttp.NewServer(kratoshttp.Middleware(Middleware(config, NewDefaultErrorHandler(), log.DefaultLogger)))
+	record := func(ctx kratoshttp.Context) error {
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "raw", nil
+		})
+		out, err := h(ctx, nil)
+		if err != nil {
+			return err
+		}
+		_, wrapped := out.(*ResponseStructure)
+		return ctx.String(http.StatusOK, strconv.FormatBool(wrapped))
+	}
+	srv.Route("/").GET("/v1/files/{id}", record)
+	srv.Route("/").POST("/v1/files/{id}", record)
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	wrapped := func(method string) string {
+		req, err := http.NewRequest(method, server.URL+"/v1/files/1", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	// 限定 HTTP 方法的模式与编码器一致，按方法 + URL 路径匹配
+	assert.Equal(t, "false", wrapped(http.MethodGet))
+	assert.Equal(t, "true", wrapped(http.MethodPost))
+}
@@ -0,0 +1,171 @@
+// Package response 提供统一响应格式中间件
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const (
+	// ProblemContentType RFC 7807 错误响应的 Content-Type
+	ProblemContentType = "application/problem+json"
+	// DefaultProblemTypeBaseURI 默认的问题类型 URI 前缀，type 为前缀 + 错误码，如 "/problems/100301"
+	DefaultProblemTypeBaseURI = "/problems/"
+	// problemTypeBlank 无业务错误码时的问题类型（RFC 7807 4.2）
+	problemTypeBlank = "about:blank"
+)
+
+// Problem RFC 7807 错误响应（application/problem+json）
+type Problem struct {
+	Type          string           `json:"type"`                     // 问题类型 URI，由错误码生成
+	Title         string           `json:"title"`                    // 问题摘要（本地化的错误信息）
+	Status        int              `json:"status"`                   // HTTP 状态码
+	Detail        string           `json:"detail,omitempty"`         // 本次错误的详细说明
+	Instance      string           `json:"instance,omitempty"`       // 出错的请求路径
+	ErrorCode     string           `json:"errorCode,omitempty"`      // 业务错误代码
+	TraceId       string           `json:"traceId,omitempty"`        // 请求追踪ID
+	InvalidParams []FieldViolation `json:"invalid-params,omitempty"` // 字段校验错误
+}
+
+// FieldViolation 字段校验错误，对应 RFC 7807 示例中的 invalid-params 成员
+type FieldViolation struct {
+	Name   string `json:"name"`   // 字段名
+	Reason string `json:"reason"` // 错误原因
+}
+
+// fieldError 单个字段校验错误（兼容 protoc-gen-validate 生成的 ValidationError）
+type fieldError interface {
+	Field() string
+	Reason() string
+}
+
+// multiFieldError 多个字段校验错误（兼容 protoc-gen-validate 生成的 MultiError）
+type multiFieldError interface {
+	AllErrors() []error
+}
+
+// WithProblemDetails 错误编码器对匹配 config.ProblemDetailsPaths 的请求输出 RFC 7807 格式，其他请求仍使用 ResponseStructure
+func WithProblemDetails(config *Config) EncoderOption {
+	return func(o *encoderOptions) {
+		o.problemConfig = config
+	}
+}
+
+// NewProblemErrorEncoder 创建 RFC 7807 错误编码器，所有错误都输出为 application/problem+json
+// errorHandler: 错误处理接口，必须提供
+// config: 配置信息，提供问题类型 URI 前缀、是否包含详细错误信息和 TraceId 头部名称，可以为 nil
+// opts: 可选参数，如 WithTraceIdHeader
+func NewProblemErrorEncoder(errorHandler ErrorHandler, config *Config, opts ...EncoderOption) func(http.ResponseWriter, *http.Request, error) {
+	if errorHandler == nil {
+		panic("ErrorHandler cannot be nil")
+	}
+	options := &encoderOptions{traceIdHeader: config.traceIdHeader()}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		writeProblem(w, NewProblem(errorHandler, config, err, r, options.traceId(w, r)))
+	}
+}
+
+// NewProblem 将错误转换为 RFC 7807 错误响应
+// config 为 nil 时使用默认的问题类型 URI 前缀，不包含详细错误信息
+func NewProblem(errorHandler ErrorHandler, config *Config, err error, r *http.Request, traceId string) *Problem {
+	code := errorHandler.GetErrorCode(err)
+	title := errorHandler.GetErrorMessage(err, false)
+	problem := &Problem{
+		Type:          config.problemType(code),
+		Title:         title,
+		Status:        errorHandler.GetHTTPStatusCode(err),
+		ErrorCode:     code,
+		TraceId:       traceId,
+		InvalidParams: fieldViolations(err),
+	}
+	if r != nil && r.URL != nil {
+		problem.Instance = r.URL.Path
+	}
+	if config != nil && config.IncludeDetailedError {
+		problem.Detail = problemDetail(errorHandler, err, title)
+	}
+	return problem
+}
+
+// useProblemDetailsFor 判断请求的错误响应是否使用 RFC 7807 格式
+// 与中间件一致，匹配请求路径或 Kratos operation（从请求 context 中的 server transport 获取）
+func (c *Config) useProblemDetailsFor(r *http.Request) bool {
+	if c.UseProblemDetails(r.Method, r.URL.Path) {
+		return true
+	}
+	if tr, ok := transport.FromServerContext(r.Context()); ok {
+		return c.UseProblemDetails("", tr.Operation())
+	}
+	return false
+}
+
+// writeProblem 输出 RFC 7807 错误响应
+func writeProblem(w http.ResponseWriter, problem *Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// problemType 根据错误码生成问题类型 URI，无业务错误码时为 "about:blank"
+func (c *Config) problemType(code string) string {
+	if _, err := strconv.Atoi(code); err != nil {
+		return problemTypeBlank
+	}
+	base := DefaultProblemTypeBaseURI
+	if c != nil && c.ProblemTypeBaseURI != "" {
+		base = c.ProblemTypeBaseURI
+	}
+	return base + code
+}
+
+// problemDetail 本次错误的详细说明：与 title 相同时使用错误原因（cause）
+func problemDetail(errorHandler ErrorHandler, err error, title string) string {
+	detail := errorHandler.GetErrorMessage(err, true)
+	if detail != title {
+		return detail
+	}
+	var kratosErr *kratosErrors.Error
+	if errors.As(err, &kratosErr) && kratosErr.Unwrap() != nil {
+		return kratosErr.Unwrap().Error()
+	}
+	return ""
+}
+
+// fieldViolations 提取字段校验错误
+// 支持 protoc-gen-validate 的校验错误（Kratos validate 中间件将其作为 cause）
+// 以及 metadata 中带 "field" 的业务错误（如 idcheck 中间件返回的错误）
+func fieldViolations(err error) []FieldViolation {
+	var multi multiFieldError
+	if errors.As(err, &multi) {
+		var violations []FieldViolation
+		for _, e := range multi.AllErrors() {
+			var fe fieldError
+			if errors.As(e, &fe) {
+				violations = append(violations, FieldViolation{Name: fe.Field(), Reason: fe.Reason()})
+			}
+		}
+		if len(violations) > 0 {
+			return violations
+		}
+	}
+
+	var fe fieldError
+	if errors.As(err, &fe) {
+		return []FieldViolation{{Name: fe.Field(), Reason: fe.Reason()}}
+	}
+
+	var kratosErr *kratosErrors.Error
+	if errors.As(err, &kratosErr) && kratosErr.Metadata["field"] != "" {
+		return []FieldViolation{{Name: kratosErr.Metadata["field"], Reason: kratosErr.Message}}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gaoyong06/go-pkg/middleware/auth/authtest"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validationError 模拟 protoc-gen-validate 生成的字段校验错误
+type validationError struct {
+	field  string
+	reason string
+}
+
+func (e validationError) Error() string  { return e.field + ": " + e.reason }
+func (e validationError) Field() string  { return e.field }
+func (e validationError) Reason() string { return e.reason }
+
+// multiValidationError 模拟 protoc-gen-validate 生成的 MultiError
+type multiValidationError []error
+
+func (m multiValidationError) Error() string      { return "multiple errors" }
+func (m multiValidationError) AllErrors() []error { return m }
+
+// problemConfig 公开 API 使用 RFC 7807 格式的配置
+var problemConfig = &Config{
+	EnableUnifiedResponse: true,
+	IncludeTraceId:        true,
+	ProblemDetailsPaths:   []string{"/public/**"},
+	ProblemTypeBaseURI:    "https://errors.example.com/",
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) *Problem {
+	t.Helper()
+	assert.Equal(t, ProblemContentType, w.Result().Header.Get("Content-Type"))
+	var problem Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	return &problem
+}
+
+func TestErrorEncoderProblemDetails(t *testing.T) {
+	encode := NewErrorEncoder(NewDefaultErrorHandler(WithStatusMapping(map[int]int{100301: 404})), WithProblemDetails(problemConfig))
+	bizErr := kratosErrors.New(100301, "BIZ_ERROR", "订单不存在")
+
+	// 匹配的路由输出 RFC 7807 格式
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/public/orders/1", nil)
+	r.Header.Set(DefaultTraceIdHeader, "trace-1")
+	encode(w, r, bizErr)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, "https://errors.example.com/100301", problem.Type)
+	assert.Equal(t, "订单不存在", problem.Title)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "/public/orders/1", problem.Instance)
+	assert.Equal(t, "100301", problem.ErrorCode)
+	assert.Equal(t, "trace-1", problem.TraceId)
+	assert.Empty(t, problem.Detail)
+
+	// 其他路由仍使用 ResponseStructure
+	w = httptest.NewRecorder()
+	encode(w, httptest.NewRequest(http.MethodGet, "/v1/orders/1", nil), bizErr)
+	assert.Equal(t, "application/json", w.Result().Header.Get("Content-Type"))
+	var resp ResponseStructure
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "100301", resp.ErrorCode)
+}
+
+func TestProblemErrorEncoder(t *testing.T) {
+	config := &Config{IncludeDetailedError: true}
+	encode := NewProblemErrorEncoder(NewDefaultErrorHandler(), config)
+
+	// protoc-gen-validate 校验错误作为 cause（Kratos validate 中间件的做法）
+	cause := multiValidationError{
+		validationError{field: "Email", reason: "value must be a valid email address"},
+		validationError{field: "Age", reason: "value must be greater than 0"},
+	}
+	w := httptest.NewRecorder()
+	encode(w, httptest.NewRequest(http.MethodPost, "/v1/users", nil), kratosErrors.BadRequest("VALIDATOR", "参数错误").WithCause(cause))
+
+	problem := decodeProblem(t, w)
+	assert.Equal(t, "/problems/400", problem.Type)
+	assert.Equal(t, "参数错误", problem.Title)
+	assert.Equal(t, "multiple errors", problem.Detail)
+	assert.Equal(t, []FieldViolation{
+		{Name: "Email", Reason: "value must be a valid email address"},
+		{Name: "Age", Reason: "value must be greater than 0"},
+	}, problem.InvalidParams)
+	assert.Regexp(t, `^[0-9a-f]{32}$`, problem.TraceId)
+
+	// metadata 中的 field（如 idcheck 中间件返回的错误）
+	w = httptest.NewRecorder()
+	bizErr := kratosErrors.New(400, "BIZ_ERROR", "缺少必填字段").WithMetadata(map[string]string{"field": "app_id"})
+	encode(w, httptest.NewRequest(http.MethodGet, "/v1/orders", nil), bizErr)
+	assert.Equal(t, []FieldViolation{{Name: "app_id", Reason: "缺少必填字段"}}, decodeProblem(t, w).InvalidParams)
+
+	// 非业务错误使用 about:blank
+	w = httptest.NewRecorder()
+	encode(w, httptest.NewRequest(http.MethodGet, "/v1/orders", nil), errors.New("boom"))
+	problem = decodeProblem(t, w)
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, http.StatusInternalServerError, problem.Status)
+}
+
+func TestMiddlewareProblemDetailsPassesErrorThrough(t *testing.T) {
+	tr := authtest.NewTransport(transport.KindHTTP, "/public/orders", nil)
+	ctx := transport.NewServerContext(context.Background(), tr)
+
+	bizErr := kratosErrors.New(100301, "BIZ_ERROR", "not found")
+	reply, err := Middleware(problemConfig, NewDefaultErrorHandler(), log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, bizErr
+	})(ctx, nil)
+
+	assert.Nil(t, reply)
+	assert.Equal(t, bizErr, err, "errors on problem+json routes are left to the error encoder")
+}
+
+func TestProblemDetailsOperationPattern(t *testing.T) {
+	config := &Config{EnableUnifiedResponse: true, ProblemDetailsPaths: []string{"/api.order.v1.Order/*"}}
+	handler := NewDefaultErrorHandler(WithStatusMapping(map[int]int{100301: 404}))
+
+	srv := kratoshttp.NewServer(
+		kratoshttp.Middleware(Middleware(config, handler, log.DefaultLogger)),
+		kratoshttp.ErrorEncoder(NewErrorEncoder(handler, WithProblemDetails(config))),
+		kratoshttp.ResponseEncoder(NewResponseEncoder(handler, config)),
+	)
+	srv.Route("/").GET("/v1/orders/{id}", func(ctx kratoshttp.Context) error {
+		kratoshttp.SetOperation(ctx, "/api.order.v1.Order/Get")
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, kratosErrors.New(100301, "BIZ_ERROR", "not found")
+		})
+		out, err := h(ctx, nil)
+		if err != nil {
+			return err
+		}
+		return ctx.Result(http.StatusOK, out)
+	})
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/v1/orders/1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, ProblemContentType, resp.Header.Get("Content-Type"))
+	var problem Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, "100301", problem.ErrorCode)
+	assert.Equal(t, resp.Header.Get(DefaultTraceIdHeader), problem.TraceId)
+}
+
+func TestErrorEncoderCustomTraceIdHeader(t *testing.T) {
+	const requestID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	config := &Config{EnableUnifiedResponse: true, TraceIdHeader: "X-Request-Id", ProblemDetailsPaths: []string{"/v1/orders/*"}}
+	handler := NewDefaultErrorHandler(WithStatusMapping(map[int]int{100301: 404}))
+
+	srv := kratoshttp.NewServer(
+		kratoshttp.Middleware(Middleware(config, handler, log.DefaultLogger)),
+		kratoshttp.ErrorEncoder(NewErrorEncoder(handler, WithProblemDetails(config))),
+		kratoshttp.ResponseEncoder(NewResponseEncoder(handler, config)),
+	)
+	srv.Route("/").GET("/v1/orders/{id}", func(ctx kratoshttp.Context) error {
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, kratosErrors.New(100301, "BIZ_ERROR", "not found")
+		})
+		_, err := h(ctx, nil)
+		return err
+	})
+	srv.Route("/").GET("/v1/users/{id}", func(ctx kratoshttp.Context) error {
+		// 模拟请求解码失败，中间件未执行
+		return kratosErrors.BadRequest("CODEC", "bad request")
+	})
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	// problem+json 路由和中间件未执行的普通路由都沿用 X-Request-Id
+	for _, path := range []string{"/v1/orders/1", "/v1/users/1"} {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Request-Id", requestID)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+
+		var body struct {
+			TraceId string `json:"traceId"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		resp.Body.Close()
+
+		assert.Equal(t, requestID, body.TraceId, path)
+		assert.Equal(t, []string{requestID}, resp.Header.Values("X-Request-Id"), path)
+		assert.Empty(t, resp.Header.Get(DefaultTraceIdHeader), path)
+	}
+}
@@ -3,40 +3,65 @@ package response
 
 import (
 	"context"
+	"crypto/rand"
+	"fmt"
 	"strings"
-	"time"
+
+	"github.com/gaoyong06/go-pkg/middleware/route"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// MatchPath 匹配路径（支持简单的通配符）
-// 支持格式：
-// - "/path" - 精确匹配
-// - "/path/*" - 前缀匹配
-// - "*/suffix" - 后缀匹配
+// MatchPath 匹配路径（支持通配符、路径参数和正则，语法见 route 包）
+//
+// Deprecated: 使用 route.Match
 func MatchPath(path, pattern string) bool {
-	// 简单的通配符匹配实现
-	if strings.HasSuffix(pattern, "*") {
-		prefix := strings.TrimSuffix(pattern, "*")
-		return strings.HasPrefix(path, prefix)
-	}
+	return route.Match(path, pattern)
+}
 
-	if strings.HasPrefix(pattern, "*") {
-		suffix := strings.TrimPrefix(pattern, "*")
-		return strings.HasSuffix(path, suffix)
-	}
+const (
+	// DefaultTraceIdHeader 默认的 TraceId 响应头（HTTP）
+	DefaultTraceIdHeader = "X-Trace-Id"
+	// traceparentHeader W3C Trace Context 请求头，格式：version-traceid-spanid-flags
+	traceparentHeader = "traceparent"
+)
 
-	return path == pattern
-}
+// traceIdKey 是 context 中存储 TraceId 的键
+type traceIdKey struct{}
+
+// TraceIdKey 导出 TraceId 键，供外部使用
+var TraceIdKey = traceIdKey{}
 
 // GetTraceIdFromContext 从上下文获取 TraceId
+// 优先级：
+// 1. SetTraceIdToContext 设置的 TraceId（response.Middleware 会为每个请求设置）
+// 2. OpenTelemetry span context（如 Kratos tracing 中间件创建的 span）
+// 3. 请求头 X-Trace-Id（由网关或上游服务的 identity.Client 传入）
+// 4. 请求头 traceparent（W3C Trace Context）
+// 5. 旧版本使用的字符串键 "trace_id"、"X-Trace-Id"（兼容未升级的调用方）
 func GetTraceIdFromContext(ctx context.Context) string {
-	if traceId := ctx.Value("trace_id"); traceId != nil {
-		if id, ok := traceId.(string); ok {
+	return traceIdFromContext(ctx, DefaultTraceIdHeader)
+}
+
+// traceIdFromContext 按 GetTraceIdFromContext 的优先级获取 TraceId，header 为携带 TraceId 的请求头名称
+func traceIdFromContext(ctx context.Context, header string) string {
+	if id, ok := ctx.Value(TraceIdKey).(string); ok && id != "" {
+		return id
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		if id := traceIdFromHeaders(tr.RequestHeader().Get, header); id != "" {
 			return id
 		}
 	}
 
-	if traceId := ctx.Value("X-Trace-Id"); traceId != nil {
-		if id, ok := traceId.(string); ok {
+	for _, key := range []string{"trace_id", "X-Trace-Id"} {
+		if id, ok := ctx.Value(key).(string); ok && id != "" {
 			return id
 		}
 	}
@@ -44,25 +69,84 @@ func GetTraceIdFromContext(ctx context.Context) string {
 	return ""
 }
 
+// traceIdFromHeaders 从请求头获取 TraceId：优先使用 header 指定的请求头，其次为 traceparent
+func traceIdFromHeaders(get func(string) string, header string) string {
+	if id := strings.TrimSpace(get(header)); validTraceId(id) {
+		return id
+	}
+	if id, ok := parseTraceparent(get(traceparentHeader)); ok {
+		return id
+	}
+	return ""
+}
+
+// maxTraceIdLength 外部传入的 TraceId 最大长度
+const maxTraceIdLength = 128
+
+// validTraceId 外部传入的 TraceId 是否可用：非空、长度有限，且只包含字母、数字和 "-_.:"，避免日志注入
+func validTraceId(id string) bool {
+	if id == "" || len(id) > maxTraceIdLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// TraceIdValuer 返回从 context 读取 TraceId 的日志 Valuer
+// 用法：log.With(logger, "trace_id", response.TraceIdValuer())，日志需要通过 log.NewHelper(...).WithContext(ctx) 输出
+func TraceIdValuer() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		if ctx == nil {
+			return ""
+		}
+		return GetTraceIdFromContext(ctx)
+	}
+}
+
 // SetTraceIdToContext 设置 TraceId 到上下文
+// 除 TraceIdKey 外仍写入历史使用的 "trace_id" 和 "X-Trace-Id" 字符串键，兼容直接按字符串键读取的旧代码
 func SetTraceIdToContext(ctx context.Context, traceId string) context.Context {
+	ctx = context.WithValue(ctx, TraceIdKey, traceId)
 	ctx = context.WithValue(ctx, "trace_id", traceId)
 	ctx = context.WithValue(ctx, "X-Trace-Id", traceId)
 	return ctx
 }
 
-// GenerateUUID 生成简单的UUID（用于 TraceId）
+// GenerateTraceId 生成随机 TraceId（32 位十六进制，与 W3C Trace Context 的 trace-id 格式一致）
+func GenerateTraceId() string {
+	var id trace.TraceID
+	for !id.IsValid() {
+		if _, err := rand.Read(id[:]); err != nil {
+			// crypto/rand 在受支持的平台上不会失败
+			panic(fmt.Sprintf("generate trace id: %v", err))
+		}
+	}
+	return id.String()
+}
+
+// GenerateUUID 生成 TraceId
+//
+// Deprecated: 使用 GenerateTraceId
 func GenerateUUID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(8)
+	return GenerateTraceId()
 }
 
-// randomString 生成随机字符串
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
+// parseTraceparent 解析 W3C traceparent 请求头，返回 trace-id
+func parseTraceparent(value string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", false
 	}
-	return string(b)
+	id, err := trace.TraceIDFromHex(strings.ToLower(parts[1]))
+	if err != nil {
+		return "", false
+	}
+	return id.String(), true
 }
-
@@ -0,0 +1,74 @@
+package response
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/gaoyong06/go-pkg/middleware/auth/authtest"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestGenerateTraceId(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := GenerateTraceId()
+		assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{32}$`), id)
+		assert.False(t, seen[id], "trace ids must be unique")
+		seen[id] = true
+	}
+}
+
+func TestGetTraceIdFromContext(t *testing.T) {
+	const otelID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	// 显式设置的 TraceId 优先
+	ctx := SetTraceIdToContext(context.Background(), "explicit")
+	assert.Equal(t, "explicit", GetTraceIdFromContext(ctx))
+	// 兼容旧版字符串键
+	assert.Equal(t, "explicit", ctx.Value("trace_id"))
+	assert.Equal(t, "explicit", ctx.Value("X-Trace-Id"))
+
+	// OpenTelemetry span context
+	traceID, err := trace.TraceIDFromHex(otelID)
+	assert.NoError(t, err)
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1}})
+	ctx = trace.ContextWithSpanContext(context.Background(), spanCtx)
+	assert.Equal(t, otelID, GetTraceIdFromContext(ctx))
+
+	// X-Trace-Id 请求头优先于 traceparent，不合法的值被忽略
+	ctx = authtest.ServerContext(context.Background(), "/op", map[string]string{
+		"X-Trace-Id":  "gateway-1",
+		"traceparent": "00-" + otelID + "-00f067aa0ba902b7-01",
+	})
+	assert.Equal(t, "gateway-1", GetTraceIdFromContext(ctx))
+	ctx = authtest.ServerContext(context.Background(), "/op", map[string]string{"X-Trace-Id": "bad id"})
+	assert.Empty(t, GetTraceIdFromContext(ctx))
+
+	// W3C traceparent 请求头
+	ctx = authtest.ServerContext(context.Background(), "/op", map[string]string{
+		"traceparent": "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+	})
+	assert.Equal(t, otelID, GetTraceIdFromContext(ctx))
+
+	// 无效的 traceparent 被忽略
+	for _, value := range []string{"garbage", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "ff-" + otelID + "-00f067aa0ba902b7-01"} {
+		ctx = authtest.ServerContext(context.Background(), "/op", map[string]string{"traceparent": value})
+		assert.Empty(t, GetTraceIdFromContext(ctx), value)
+	}
+
+	// 兼容旧版本的字符串键
+	ctx = context.WithValue(context.Background(), "trace_id", "legacy")
+	assert.Equal(t, "legacy", GetTraceIdFromContext(ctx))
+}
+
+func TestTraceIdValuer(t *testing.T) {
+	var buf bytes.Buffer
+	helper := log.NewHelper(log.With(log.NewStdLogger(&buf), "trace_id", TraceIdValuer()))
+
+	helper.WithContext(SetTraceIdToContext(context.Background(), "trace-1")).Info("order created")
+	assert.Contains(t, buf.String(), "trace_id=trace-1")
+}
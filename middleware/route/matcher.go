@@ -0,0 +1,249 @@
+// Package route 提供中间件共用的路由匹配工具
+//
+// 模式语法：
+//   - "/health"                      精确匹配
+//   - "/v1/users/{id}"、"/v1/users/:id" 路径参数，匹配一个路径段
+//   - "/v1/*/profile"                * 匹配一个路径段内的任意字符
+//   - "/static/**"、"/v1/**/export"  ** 匹配零个或多个路径段
+//   - "/swagger/*"、"*/health"       以 * 结尾或开头时为前缀/后缀匹配（兼容旧配置）
+//   - "regex:^/v[0-9]+/internal/"    正则表达式（不自动添加 ^ $）
+//   - "GET /v1/users/*"、"GET,HEAD /files/**" 限定 HTTP 方法
+//   - "/api.user.v1.User/*"          Kratos operation 名称同样适用
+//
+// 模式在首次使用时编译并缓存，也可以通过 NewMatcher 预先编译和校验
+package route
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// regexPrefix 正则表达式模式的前缀
+const regexPrefix = "regex:"
+
+// Rule 编译后的匹配规则
+type Rule struct {
+	pattern string
+	methods []string // 为空表示不限方法
+	literal string   // 不含通配符时使用字符串比较
+	re      *regexp.Regexp
+}
+
+// Compile 编译单个模式
+func Compile(pattern string) (*Rule, error) {
+	pattern = strings.TrimSpace(pattern)
+	r := &Rule{pattern: pattern}
+
+	methods, path := splitMethods(pattern)
+	r.methods = methods
+
+	if strings.HasPrefix(path, regexPrefix) {
+		re, err := regexp.Compile(strings.TrimPrefix(path, regexPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid route pattern %q: %w", pattern, err)
+		}
+		r.re = re
+		return r, nil
+	}
+
+	if IsLiteral(path) {
+		r.literal = path
+		return r, nil
+	}
+
+	re, err := regexp.Compile(globToRegexp(path))
+	if err != nil {
+		return nil, fmt.Errorf("invalid route pattern %q: %w", pattern, err)
+	}
+	r.re = re
+	return r, nil
+}
+
+// Pattern 返回原始模式
+func (r *Rule) Pattern() string {
+	return r.pattern
+}
+
+// Match 判断请求是否匹配规则
+// method 为空（如 gRPC 调用或只有路径的场景）时，限定了 HTTP 方法的规则不匹配
+func (r *Rule) Match(method, path string) bool {
+	if len(r.methods) > 0 {
+		matched := false
+		for _, m := range r.methods {
+			if strings.EqualFold(m, method) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if r.re != nil {
+		return r.re.MatchString(path)
+	}
+	return path == r.literal
+}
+
+// Matcher 预编译的一组规则，匹配任意一条即视为匹配
+type Matcher struct {
+	rules []*Rule
+}
+
+// NewMatcher 编译一组模式，任意模式无效时返回错误
+func NewMatcher(patterns []string) (*Matcher, error) {
+	m := &Matcher{rules: make([]*Rule, 0, len(patterns))}
+	for _, pattern := range patterns {
+		rule, err := Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		m.rules = append(m.rules, rule)
+	}
+	return m, nil
+}
+
+// Match 判断请求是否匹配任意规则
+func (m *Matcher) Match(method, path string) bool {
+	if m == nil {
+		return false
+	}
+	for _, rule := range m.rules {
+		if rule.Match(method, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// compiled 模式编译缓存：pattern -> *Rule（无效模式缓存为 nil）
+var compiled sync.Map
+
+// cachedRule 获取缓存的编译结果，无效模式返回 nil
+func cachedRule(pattern string) *Rule {
+	if rule, ok := compiled.Load(pattern); ok {
+		return rule.(*Rule)
+	}
+	rule, err := Compile(pattern)
+	if err != nil {
+		rule = nil
+	}
+	actual, _ := compiled.LoadOrStore(pattern, rule)
+	return actual.(*Rule)
+}
+
+// Match 判断路径是否匹配模式（不限定 HTTP 方法），无效模式不匹配任何路径
+func Match(path, pattern string) bool {
+	return MatchRequest("", path, pattern)
+}
+
+// MatchRequest 判断请求（HTTP 方法 + 路径或 Kratos operation）是否匹配模式
+func MatchRequest(method, path, pattern string) bool {
+	rule := cachedRule(pattern)
+	return rule != nil && rule.Match(method, path)
+}
+
+// MatchAny 判断请求是否匹配任意模式
+func MatchAny(method, path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if MatchRequest(method, path, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLiteral 模式是否为不含通配符、路径参数和正则的精确路径
+func IsLiteral(pattern string) bool {
+	_, path := splitMethods(strings.TrimSpace(pattern))
+	if strings.HasPrefix(path, regexPrefix) {
+		return false
+	}
+	if strings.ContainsAny(path, "*{") {
+		return false
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			return false
+		}
+	}
+	return true
+}
+
+// splitMethods 拆分 HTTP 方法限定，如 "GET,POST /path" -> (["GET", "POST"], "/path")
+func splitMethods(pattern string) ([]string, string) {
+	prefix, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		return nil, pattern
+	}
+	for _, c := range prefix {
+		if (c < 'A' || c > 'Z') && c != ',' {
+			return nil, pattern
+		}
+	}
+	return strings.Split(prefix, ","), strings.TrimSpace(path)
+}
+
+// globToRegexp 将通配符模式转换为正则表达式
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+
+	// 兼容旧语法：以单个 * 开头为后缀匹配
+	if strings.HasPrefix(pattern, "*") && !strings.HasPrefix(pattern, "**") {
+		b.WriteString(".*")
+		pattern = pattern[1:]
+	}
+	// 兼容旧语法：以单个 * 结尾为前缀匹配
+	suffix := ""
+	if strings.HasSuffix(pattern, "*") && !strings.HasSuffix(pattern, "**") {
+		suffix = ".*"
+		pattern = pattern[:len(pattern)-1]
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '/' && pattern[i+1:] == "**":
+			// 结尾的 "/**" 匹配零个或多个路径段，"/static/**" 同时匹配 "/static"
+			b.WriteString("(?:/.*)?")
+			i = len(pattern)
+		case c == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			i++
+			// "/**/" 匹配零个或多个路径段
+			if i+1 < len(pattern) && pattern[i+1] == '/' && i >= 2 && pattern[i-2] == '/' {
+				b.WriteString("(?:.*/)?")
+				i++
+			} else {
+				b.WriteString(".*")
+			}
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '{':
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(pattern[i:]))
+				i = len(pattern)
+				continue
+			}
+			b.WriteString("[^/]+")
+			i += end
+		case c == ':' && (i == 0 || pattern[i-1] == '/'):
+			end := strings.IndexByte(pattern[i:], '/')
+			if end < 0 {
+				end = len(pattern) - i
+			}
+			b.WriteString("[^/]+")
+			i += end - 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString(suffix)
+	b.WriteString("$")
+	return b.String()
+}
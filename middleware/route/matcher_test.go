@@ -0,0 +1,65 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchRequest(t *testing.T) {
+	tests := []struct {
+		pattern string
+		method  string
+		path    string
+		want    bool
+	}{
+		{"/health", "", "/health", true},
+		{"/health", "", "/healthz", false},
+		{"/swagger/*", "", "/swagger/ui/index.html", true},
+		{"*/health", "", "/api/health", true},
+		{"/v1/*/profile", "", "/v1/users/profile", true},
+		{"/v1/*/profile", "", "/v1/users/1/profile", false},
+		{"/static/**", "", "/static/css/app.css", true},
+		{"/static/**", "", "/static", true},
+		{"/static/**", "", "/staticfiles", false},
+		{"/v1/**/export", "", "/v1/export", true},
+		{"/v1/**/export", "", "/v1/orders/2024/export", true},
+		{"/v1/users/{id}", "", "/v1/users/42", true},
+		{"/v1/users/{id}", "", "/v1/users/42/orders", false},
+		{"/v1/users/:id/orders", "", "/v1/users/42/orders", true},
+		{"regex:^/v[0-9]+/internal/", "", "/v2/internal/metrics", true},
+		{"regex:^/v[0-9]+/internal/", "", "/vx/internal/metrics", false},
+		{"GET /v1/files/**", "GET", "/v1/files/a.txt", true},
+		{"GET /v1/files/**", "POST", "/v1/files/a.txt", false},
+		{"GET,HEAD /v1/files/**", "head", "/v1/files/a.txt", true},
+		{"GET /v1/files/**", "", "/v1/files/a.txt", false},
+		{"/api.user.v1.User/*", "", "/api.user.v1.User/GetUser", true},
+		{"/api.user.v1.User/GetUser", "", "/api.user.v1.UserXGetUser", false},
+		{"regex:(", "", "(", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MatchRequest(tt.method, tt.path, tt.pattern), "%s %s %s", tt.pattern, tt.method, tt.path)
+	}
+}
+
+func TestNewMatcher(t *testing.T) {
+	m, err := NewMatcher([]string{"/health", "POST /v1/hooks/**"})
+	require.NoError(t, err)
+	assert.True(t, m.Match("", "/health"))
+	assert.True(t, m.Match("POST", "/v1/hooks/stripe"))
+	assert.False(t, m.Match("GET", "/v1/hooks/stripe"))
+
+	_, err = NewMatcher([]string{"regex:["})
+	assert.Error(t, err)
+}
+
+func TestIsLiteral(t *testing.T) {
+	assert.True(t, IsLiteral("/api.user.v1.User/GetUser"))
+	assert.True(t, IsLiteral("GET /health"))
+	assert.False(t, IsLiteral("/v1/users/{id}"))
+	assert.False(t, IsLiteral("/v1/users/:id"))
+	assert.False(t, IsLiteral("/v1/*"))
+	assert.False(t, IsLiteral("regex:^/v1"))
+}
@@ -0,0 +1,30 @@
+// Package tenant 提供基于应用 ID 的多租户工具：租户上下文、Redis 键构造、租户日志和跨租户访问防护
+package tenant
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// LogKey 日志中租户字段的名称
+const LogKey = "app_id"
+
+// Valuer 返回从 context 读取租户的日志 Valuer，context 中没有租户时输出空字符串
+// 用法：log.With(logger, tenant.LogKey, tenant.Valuer())，需要通过 log.NewHelper(...).WithContext(ctx) 输出
+func Valuer() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		if ctx == nil {
+			return ""
+		}
+		appID, _ := FromContext(ctx)
+		return appID
+	}
+}
+
+// Logger 包装 logger，在每条日志中加入 app_id 字段
+// 日志需要通过 log.NewHelper(logger).WithContext(ctx) 或 log.WithContext(ctx, logger) 输出，
+// 否则 Valuer 拿不到请求 context
+func Logger(logger log.Logger) log.Logger {
+	return log.With(logger, LogKey, Valuer())
+}
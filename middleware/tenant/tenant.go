@@ -0,0 +1,129 @@
+// Package tenant 提供基于应用 ID 的多租户工具：租户上下文、Redis 键构造、租户日志和跨租户访问防护
+//
+// 租户即 app_id 中间件（或 identity 中间件）写入 context 的应用 ID。
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/app_id"
+	"github.com/gaoyong06/go-pkg/middleware/i18n"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+var (
+	// ErrNoTenant context 中没有租户
+	ErrNoTenant = errors.New("tenant not found in context")
+	// ErrInvalidTenant 租户 ID 包含键分隔符等非法字符
+	ErrInvalidTenant = errors.New("invalid tenant id")
+	// ErrCrossTenant 访问了其他租户的资源
+	ErrCrossTenant = errors.New("cross-tenant access")
+)
+
+// Resource 归属于某个租户的资源，proto 生成的带 app_id 字段的消息天然满足该接口
+type Resource interface {
+	GetAppId() string
+}
+
+// FromContext 获取当前租户（应用 ID）
+func FromContext(ctx context.Context) (string, bool) {
+	appID := app_id.GetAppIDFromContext(ctx)
+	return appID, appID != ""
+}
+
+// Require 获取当前租户，context 中没有租户时返回 ErrNoTenant，租户 ID 非法时返回 ErrInvalidTenant（见 Validate）
+func Require(ctx context.Context) (string, error) {
+	appID, ok := FromContext(ctx)
+	if !ok {
+		return "", ErrNoTenant
+	}
+	if err := Validate(appID); err != nil {
+		return "", err
+	}
+	return appID, nil
+}
+
+// invalidTenantChars 租户 ID 中不允许出现的字符：键分隔符、空白字符，以及 Redis SCAN/KEYS 的通配符
+// 通配符会使 Pattern 生成的模式匹配其他租户的键（如 X-App-Id: * 生成 "tenant:*:*"）
+const invalidTenantChars = keySeparator + " \t\r\n*?[]\\"
+
+// Validate 校验租户 ID，为空时返回 ErrNoTenant，包含分隔符、空白或通配符时返回 ErrInvalidTenant
+func Validate(appID string) error {
+	if appID == "" {
+		return ErrNoTenant
+	}
+	if strings.ContainsAny(appID, invalidTenantChars) {
+		return ErrInvalidTenant
+	}
+	return nil
+}
+
+// WithTenant 将租户存入 context（用于后台任务、消息消费者等没有经过中间件的场景）
+func WithTenant(ctx context.Context, appID string) context.Context {
+	return app_id.WithAppID(ctx, appID)
+}
+
+// Check 校验资源归属，资源租户与当前租户不一致时返回本地化的 ErrCodeForbidden
+// 返回的错误可以通过 errors.Is(err, ErrCrossTenant) 识别；context 中没有租户时同样拒绝
+// 检测到跨租户访问时记录告警日志（可能是越权攻击或数据错误）
+func Check(ctx context.Context, resourceAppID string) error {
+	appID, err := Require(ctx)
+	if err == nil && appID == resourceAppID {
+		return nil
+	}
+	if err == nil {
+		err = ErrCrossTenant
+	}
+
+	log.NewHelper(log.GetLogger()).WithContext(ctx).Warnf("tenant guard: denied access, tenant=%q resource_tenant=%q err=%v",
+		appID, resourceAppID, err)
+	return newForbiddenError(ctx).WithCause(err)
+}
+
+// CheckResource 校验资源归属，resource 为 nil 时不做校验（由调用方处理不存在的情况）
+func CheckResource(ctx context.Context, resource Resource) error {
+	if resource == nil {
+		return nil
+	}
+	return Check(ctx, resource.GetAppId())
+}
+
+// Filter 过滤列表，只保留当前租户的资源；context 中没有租户时返回空列表
+func Filter[T Resource](ctx context.Context, resources []T) []T {
+	appID, err := Require(ctx)
+	if err != nil {
+		return nil
+	}
+	filtered := make([]T, 0, len(resources))
+	for _, r := range resources {
+		if r.GetAppId() == appID {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// errorManager Check 使用的 ErrorManager，未设置时使用内置文案
+var errorManager atomic.Pointer[pkgErrors.ErrorManager]
+
+// SetErrorManager 设置 Check 使用的 ErrorManager，拒绝错误的文案来自服务的 errors.json（默认使用内置中英文文案）
+// 通常在服务启动时调用一次
+func SetErrorManager(manager *pkgErrors.ErrorManager) {
+	errorManager.Store(manager)
+}
+
+// tenantMessages 未配置 ErrorManager 时使用的内置文案
+var tenantMessages = pkgErrors.Messages{
+	"zh-CN": {pkgErrors.ErrCodeForbidden: "无权访问该资源"},
+	"en-US": {pkgErrors.ErrCodeForbidden: "You do not have access to this resource"},
+}
+
+// newForbiddenError 创建本地化的 ErrCodeForbidden 业务错误
+func newForbiddenError(ctx context.Context) *kratosErrors.Error {
+	return pkgErrors.NewBizErrorWithFallback(ctx, errorManager.Load(), tenantMessages, i18n.Language(ctx), pkgErrors.ErrCodeForbidden)
+}
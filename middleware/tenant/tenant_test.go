@@ -0,0 +1,115 @@
+package tenant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/ratelimit"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// order 测试用的租户资源
+type order struct {
+	ID    string
+	AppID string
+}
+
+func (o *order) GetAppId() string { return o.AppID }
+
+// recordingLimiter 记录限流键的限流器
+type recordingLimiter struct {
+	keys []string
+}
+
+func (l *recordingLimiter) Allow(ctx context.Context, key string, config *ratelimit.Config) error {
+	l.keys = append(l.keys, key)
+	return nil
+}
+
+func TestKeys(t *testing.T) {
+	ctx := WithTenant(context.Background(), "app-1")
+
+	key, err := Key(ctx, "sms", "user", "123")
+	require.NoError(t, err)
+	assert.Equal(t, "tenant:app-1:sms:user:123", key)
+
+	builder := NewKeyBuilder("cache")
+	pattern, err := builder.Pattern(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "cache:app-1:*", pattern)
+	key, err = builder.KeyFor("app-2", "profile")
+	require.NoError(t, err)
+	assert.Equal(t, "cache:app-2:profile", key)
+
+	// 通配符会使清理任务的 SCAN 模式匹配所有租户的键
+	for _, appID := range []string{"*", "app-?", "app-[12]", `app\*`} {
+		_, err = Key(WithTenant(context.Background(), appID), "sms")
+		assert.ErrorIs(t, err, ErrInvalidTenant, appID)
+		_, err = builder.Pattern(WithTenant(context.Background(), appID))
+		assert.ErrorIs(t, err, ErrInvalidTenant, appID)
+		_, err = builder.KeyFor(appID, "profile")
+		assert.ErrorIs(t, err, ErrInvalidTenant, appID)
+	}
+	_, err = builder.KeyFor("", "profile")
+	assert.ErrorIs(t, err, ErrNoTenant)
+
+	_, err = Key(context.Background(), "sms")
+	assert.ErrorIs(t, err, ErrNoTenant)
+
+	// 租户 ID 中的分隔符会导致不同租户的键冲突
+	_, err = Key(WithTenant(context.Background(), "app:1"), "sms")
+	assert.ErrorIs(t, err, ErrInvalidTenant)
+}
+
+func TestLimiter(t *testing.T) {
+	next := &recordingLimiter{}
+	limiter := NewLimiter(next, nil)
+
+	require.NoError(t, limiter.Allow(WithTenant(context.Background(), "app-1"), "sms:user:1", nil))
+	require.NoError(t, limiter.Allow(WithTenant(context.Background(), "app-2"), "sms:user:1", nil))
+	assert.Equal(t, []string{"tenant:app-1:sms:user:1", "tenant:app-2:sms:user:1"}, next.keys)
+
+	assert.ErrorIs(t, limiter.Allow(context.Background(), "sms:user:1", nil), ErrNoTenant)
+}
+
+func TestCheck(t *testing.T) {
+	ctx := WithTenant(context.Background(), "app-1")
+
+	assert.NoError(t, Check(ctx, "app-1"))
+	assert.NoError(t, CheckResource(ctx, &order{AppID: "app-1"}))
+	assert.NoError(t, CheckResource(ctx, nil))
+
+	err := CheckResource(ctx, &order{AppID: "app-2"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrCrossTenant))
+	assert.Equal(t, int32(pkgErrors.ErrCodeForbidden), kratosErrors.FromError(err).Code)
+
+	err = Check(context.Background(), "app-1")
+	assert.True(t, errors.Is(err, ErrNoTenant))
+}
+
+func TestFilter(t *testing.T) {
+	orders := []*order{{ID: "1", AppID: "app-1"}, {ID: "2", AppID: "app-2"}, {ID: "3", AppID: "app-1"}}
+
+	filtered := Filter(WithTenant(context.Background(), "app-1"), orders)
+	require.Len(t, filtered, 2)
+	assert.Equal(t, "1", filtered[0].ID)
+	assert.Equal(t, "3", filtered[1].ID)
+
+	assert.Empty(t, Filter(context.Background(), orders))
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	helper := log.NewHelper(Logger(log.NewStdLogger(&buf)))
+
+	helper.WithContext(WithTenant(context.Background(), "app-1")).Info("order created")
+	assert.Contains(t, buf.String(), "app_id=app-1")
+	assert.Contains(t, buf.String(), "order created")
+}
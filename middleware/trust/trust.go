@@ -0,0 +1,416 @@
+// Package trust 提供网关注入身份请求头的可信来源校验
+//
+// X-App-Id、X-End-User-Id、X-Developer-Id 等请求头由 API Gateway 在认证后注入，
+// 下游服务直接信任它们；绕过网关直连服务的客户端可以伪造这些请求头冒充任意用户。
+// 本包的中间件只在请求来自受信任的网关地址（直连 IP 属于配置的 CIDR），
+// 或携带有效的网关 HMAC 签名时保留这些请求头，否则将其删除；严格模式下直接拒绝请求。
+// 签名绑定请求方法和 URI，配置 NonceStore（WithNonceStore）后每个签名只能使用一次。
+// 中间件需要放在 app_id、user_id、developer_id、identity 等中间件之前。
+package trust
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/auth"
+	"github.com/gaoyong06/go-pkg/middleware/i18n"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Config 可信来源配置
+type Config struct {
+	// 受信任的网关地址，CIDR 或单个 IP，如 ["10.0.0.0/8", "192.168.1.10"]
+	// 只检查直连地址（RemoteAddr / gRPC peer），不读取 X-Forwarded-For
+	TrustedProxies []string `json:"trusted_proxies" yaml:"trusted_proxies"`
+
+	// 网关签名密钥，为空时不接受签名方式
+	Secret string `json:"secret" yaml:"secret"`
+
+	// 网关签名请求头，默认 "X-Gateway-Signature"（HMAC-SHA256，hex 编码）
+	SignatureHeader string `json:"signature_header" yaml:"signature_header"`
+
+	// 网关签名时间戳请求头（Unix 秒），默认 "X-Gateway-Timestamp"
+	TimestampHeader string `json:"timestamp_header" yaml:"timestamp_header"`
+
+	// 网关签名 nonce 请求头，默认 "X-Gateway-Nonce"；配置 NonceStore 时必填，用于拒绝重放的请求
+	NonceHeader string `json:"nonce_header" yaml:"nonce_header"`
+
+	// 签名时间戳允许的时钟偏差，默认 5 分钟；未配置 NonceStore 时，截获的签名在该时间内可以对同一请求重放
+	MaxClockSkew time.Duration `json:"max_clock_skew" yaml:"max_clock_skew"`
+
+	// 受保护的身份请求头，默认 ["X-App-Id", "X-End-User-Id", "X-Developer-Id"]
+	Headers []string `json:"headers" yaml:"headers"`
+
+	// 受保护的 Query 参数（仅 HTTP），默认 ["appId"]
+	QueryParams []string `json:"query_params" yaml:"query_params"`
+
+	// 严格模式：不可信来源携带受保护的请求头或 Query 参数时拒绝请求，而不是删除后放行
+	Strict bool `json:"strict" yaml:"strict"`
+}
+
+const (
+	defaultSignatureHeader = "X-Gateway-Signature"
+	defaultTimestampHeader = "X-Gateway-Timestamp"
+	defaultNonceHeader     = "X-Gateway-Nonce"
+	defaultMaxClockSkew    = 5 * time.Minute
+)
+
+var (
+	// defaultHeaders 默认受保护的身份请求头
+	defaultHeaders = []string{"X-App-Id", "X-End-User-Id", "X-Developer-Id"}
+	// defaultQueryParams 默认受保护的 Query 参数
+	defaultQueryParams = []string{"appId"}
+)
+
+// timeNow 当前时间，测试时可替换
+var timeNow = time.Now
+
+// Policy 可信来源策略
+type Policy struct {
+	config   Config
+	networks []*net.IPNet
+	manager  *pkgErrors.ErrorManager
+	nonces   auth.NonceStore
+}
+
+// Option 可信来源策略选项
+type Option func(*Policy)
+
+// WithErrorManager 使用 ErrorManager 生成本地化的拒绝错误（默认使用内置中英文文案）
+func WithErrorManager(manager *pkgErrors.ErrorManager) Option {
+	return func(p *Policy) {
+		p.manager = manager
+	}
+}
+
+// WithNonceStore 使用 nonce 存储拒绝重放的签名请求，签名必须携带 nonce 请求头
+// 多实例部署使用 auth.NewRedisNonceStore，nonce 保留时间为 MaxClockSkew 的 2 倍
+func WithNonceStore(store auth.NonceStore) Option {
+	return func(p *Policy) {
+		p.nonces = store
+	}
+}
+
+// NewPolicy 创建可信来源策略，地址格式无效时返回错误
+func NewPolicy(config *Config, opts ...Option) (*Policy, error) {
+	conf := Config{}
+	if config != nil {
+		conf = *config
+	}
+	if conf.SignatureHeader == "" {
+		conf.SignatureHeader = defaultSignatureHeader
+	}
+	if conf.TimestampHeader == "" {
+		conf.TimestampHeader = defaultTimestampHeader
+	}
+	if conf.NonceHeader == "" {
+		conf.NonceHeader = defaultNonceHeader
+	}
+	if conf.MaxClockSkew <= 0 {
+		conf.MaxClockSkew = defaultMaxClockSkew
+	}
+	if len(conf.Headers) == 0 {
+		conf.Headers = defaultHeaders
+	}
+	if len(conf.QueryParams) == 0 {
+		conf.QueryParams = defaultQueryParams
+	}
+
+	p := &Policy{config: conf}
+	for _, addr := range conf.TrustedProxies {
+		network, err := parseNetwork(addr)
+		if err != nil {
+			return nil, err
+		}
+		p.networks = append(p.networks, network)
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+// parseNetwork 解析 CIDR 或单个 IP
+func parseNetwork(addr string) (*net.IPNet, error) {
+	addr = strings.TrimSpace(addr)
+	if strings.Contains(addr, "/") {
+		_, network, err := net.ParseCIDR(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", addr, err)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid trusted proxy %q", addr)
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 8 * net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// TrustedAddr 直连地址是否属于受信任的网关
+func (p *Policy) TrustedAddr(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign 按网关签名规则为请求头签名，写入时间戳、nonce 和签名请求头
+// method、uri 为转发给服务的请求方法和 URI（路径和 Query，如 "/v1/orders?appId=app-1"）；gRPC 请求的 method 为 "POST"、uri 为 operation
+// 供 Go 实现的网关、内部工具和测试使用
+func (p *Policy) Sign(method, uri string, header transport.Header) error {
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(timeNow().Unix(), 10)
+	header.Set(p.config.TimestampHeader, timestamp)
+	header.Set(p.config.NonceHeader, nonce)
+	header.Set(p.config.SignatureHeader, p.signature(method, uri, timestamp, nonce, header))
+	return nil
+}
+
+// signature 计算签名
+// 签名内容依次为以下各行（以 "\n" 结尾）：时间戳、nonce、大写的请求方法、请求 URI，
+// 以及每个受保护请求头的 "小写名称:值"（缺失的请求头值为空）
+func (p *Policy) signature(method, uri, timestamp, nonce string, header transport.Header) string {
+	var b strings.Builder
+	for _, line := range []string{timestamp, nonce, strings.ToUpper(method), uri} {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	for _, name := range p.config.Headers {
+		b.WriteString(strings.ToLower(name))
+		b.WriteString(":")
+		b.WriteString(strings.TrimSpace(header.Get(name)))
+		b.WriteString("\n")
+	}
+	mac := hmac.New(sha256.New, []byte(p.config.Secret))
+	mac.Write([]byte(b.String()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validSignature 请求是否携带有效的网关签名；配置 NonceStore 时同一 nonce 只能使用一次
+func (p *Policy) validSignature(ctx context.Context, tr transport.Transporter) bool {
+	if p.config.Secret == "" {
+		return false
+	}
+	header := tr.RequestHeader()
+	signature := strings.ToLower(strings.TrimSpace(header.Get(p.config.SignatureHeader)))
+	timestamp := strings.TrimSpace(header.Get(p.config.TimestampHeader))
+	nonce := strings.TrimSpace(header.Get(p.config.NonceHeader))
+	if signature == "" || timestamp == "" {
+		return false
+	}
+	if p.nonces != nil && nonce == "" {
+		return false
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := timeNow().Sub(time.Unix(ts, 0)); skew > p.config.MaxClockSkew || skew < -p.config.MaxClockSkew {
+		return false
+	}
+
+	method, uri := requestTarget(tr)
+	if !hmac.Equal([]byte(signature), []byte(p.signature(method, uri, timestamp, nonce, header))) {
+		return false
+	}
+
+	// 签名通过后再记录 nonce，避免伪造请求占用合法 nonce
+	if p.nonces != nil {
+		fresh, err := p.nonces.Use(ctx, "gateway:"+nonce, 2*p.config.MaxClockSkew)
+		if err != nil || !fresh {
+			return false
+		}
+	}
+	return true
+}
+
+// requestTarget 参与签名的请求方法和 URI：HTTP 为原始请求的方法和 RequestURI，gRPC 为 "POST" 和 operation
+func requestTarget(tr transport.Transporter) (string, string) {
+	if httpTr, ok := tr.(*kratoshttp.Transport); ok && httpTr.Request() != nil {
+		r := httpTr.Request()
+		return r.Method, r.URL.RequestURI()
+	}
+	return http.MethodPost, tr.Operation()
+}
+
+// newNonce 生成随机 nonce
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Trusted 判断请求是否来自受信任的网关（直连地址受信任或签名有效）
+func (p *Policy) Trusted(ctx context.Context, tr transport.Transporter) bool {
+	if p.TrustedAddr(remoteIP(ctx, tr)) {
+		return true
+	}
+	return p.validSignature(ctx, tr)
+}
+
+// trustedKey 是 context 中存储来源是否可信的键
+type trustedKey struct{}
+
+// TrustedKey 导出来源可信键，供外部使用
+var TrustedKey = trustedKey{}
+
+// IsTrusted 请求是否已通过可信来源校验（未使用 Middleware 时返回 false）
+func IsTrusted(ctx context.Context) bool {
+	trusted, _ := ctx.Value(TrustedKey).(bool)
+	return trusted
+}
+
+// Middleware 可信来源中间件
+// 来源可信时原样放行；不可信时删除受保护的请求头、gRPC metadata 和 Query 参数，
+// 严格模式下若请求携带了这些字段则返回 ErrCodeForbidden
+func Middleware(policy *Policy, logger log.Logger) middleware.Middleware {
+	logHelper := log.NewHelper(logger)
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			if policy.Trusted(ctx, tr) {
+				return handler(context.WithValue(ctx, TrustedKey, true), req)
+			}
+
+			ctx, stripped := policy.strip(ctx, tr)
+			if len(stripped) > 0 {
+				if policy.config.Strict {
+					logHelper.WithContext(ctx).Warnf("trust middleware: rejected untrusted identity fields %v from %s, operation=%s",
+						stripped, remoteIP(ctx, tr), tr.Operation())
+					return nil, policy.newError(ctx)
+				}
+				logHelper.WithContext(ctx).Warnf("trust middleware: stripped untrusted identity fields %v from %s, operation=%s",
+					stripped, remoteIP(ctx, tr), tr.Operation())
+			}
+			return handler(context.WithValue(ctx, TrustedKey, false), req)
+		}
+	}
+}
+
+// strip 删除受保护的请求头、gRPC metadata 和 Query 参数，返回被删除的字段名
+func (p *Policy) strip(ctx context.Context, tr transport.Transporter) (context.Context, []string) {
+	var stripped []string
+	header := tr.RequestHeader()
+	httpTr, isHTTP := tr.(*kratoshttp.Transport)
+
+	for _, name := range p.config.Headers {
+		if header.Get(name) == "" {
+			continue
+		}
+		stripped = append(stripped, name)
+		if isHTTP && httpTr.Request() != nil {
+			httpTr.Request().Header.Del(name)
+		} else {
+			header.Set(name, "")
+		}
+	}
+
+	// gRPC 的 FromIncomingContext 返回副本，需要替换 context 中的 metadata
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		changed := false
+		for _, name := range p.config.Headers {
+			key := strings.ToLower(name)
+			if len(md.Get(key)) == 0 {
+				continue
+			}
+			if !contains(stripped, name) {
+				stripped = append(stripped, name)
+			}
+			md.Delete(key)
+			changed = true
+		}
+		if changed {
+			ctx = metadata.NewIncomingContext(ctx, md)
+		}
+	}
+
+	if isHTTP && httpTr.Request() != nil && httpTr.Request().URL != nil {
+		u := httpTr.Request().URL
+		query := u.Query()
+		changed := false
+		for _, name := range p.config.QueryParams {
+			if !query.Has(name) {
+				continue
+			}
+			stripped = append(stripped, name)
+			query.Del(name)
+			changed = true
+		}
+		if changed {
+			u.RawQuery = query.Encode()
+		}
+	}
+	return ctx, stripped
+}
+
+// remoteIP 获取直连 IP：HTTP 使用 RemoteAddr，gRPC 使用 peer 地址
+func remoteIP(ctx context.Context, tr transport.Transporter) string {
+	addr := ""
+	if httpTr, ok := tr.(*kratoshttp.Transport); ok && httpTr.Request() != nil {
+		addr = httpTr.Request().RemoteAddr
+	} else if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// contains 切片是否包含字符串
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// trustMessages 未配置 ErrorManager 时使用的内置文案
+var trustMessages = pkgErrors.Messages{
+	"zh-CN": {pkgErrors.ErrCodeForbidden: "请求来源不可信"},
+	"en-US": {pkgErrors.ErrCodeForbidden: "Untrusted request source"},
+}
+
+// newError 创建本地化的拒绝错误
+func (p *Policy) newError(ctx context.Context) *kratosErrors.Error {
+	return pkgErrors.NewBizErrorWithFallback(ctx, p.manager, trustMessages, i18n.Language(ctx), pkgErrors.ErrCodeForbidden)
+}
@@ -0,0 +1,206 @@
+package trust
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pkgErrors "github.com/gaoyong06/go-pkg/errors"
+	"github.com/gaoyong06/go-pkg/middleware/app_id"
+	"github.com/gaoyong06/go-pkg/middleware/auth"
+	"github.com/gaoyong06/go-pkg/middleware/auth/authtest"
+	"github.com/gaoyong06/go-pkg/middleware/user_id"
+	kratosErrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	kratoshttp "github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// newServer 启动带可信来源中间件和 app_id 中间件的 HTTP 服务，handler 返回提取到的 appId
+func newServer(t *testing.T, policy *Policy) *httptest.Server {
+	t.Helper()
+	srv := kratoshttp.NewServer(kratoshttp.Middleware(
+		Middleware(policy, log.DefaultLogger),
+		app_id.Middleware(),
+	))
+	handle := func(ctx kratoshttp.Context) error {
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return app_id.GetAppIDFromContext(ctx), nil
+		})
+		out, err := h(ctx, nil)
+		if err != nil {
+			// 业务错误码不是合法的 HTTP 状态码，服务中由 response 包的错误编码器处理
+			return ctx.String(http.StatusForbidden, err.Error())
+		}
+		return ctx.String(http.StatusOK, out.(string))
+	}
+	srv.Route("/").GET("/whoami", handle)
+	srv.Route("/").GET("/admin/whoami", handle)
+	server := httptest.NewServer(srv)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// get 发送请求，返回状态码和响应体
+func get(t *testing.T, url string, headers map[string]string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestMiddlewareTrustedProxy(t *testing.T) {
+	policy, err := NewPolicy(&Config{TrustedProxies: []string{"127.0.0.0/8"}})
+	require.NoError(t, err)
+	server := newServer(t, policy)
+
+	status, body := get(t, server.URL+"/whoami", map[string]string{"X-App-Id": "app-1"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "app-1", body)
+}
+
+func TestMiddlewareStripsUntrustedHeadersAndQuery(t *testing.T) {
+	policy, err := NewPolicy(&Config{TrustedProxies: []string{"10.0.0.0/8"}})
+	require.NoError(t, err)
+	server := newServer(t, policy)
+
+	status, body := get(t, server.URL+"/whoami", map[string]string{"X-App-Id": "app-1"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, body)
+
+	status, body = get(t, server.URL+"/whoami?appId=app-1", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, body)
+}
+
+// signedHeaders 网关为 GET uri 生成的签名请求头
+func signedHeaders(t *testing.T, policy *Policy, uri string, appID string) map[string]string {
+	t.Helper()
+	header := authtest.Header{}
+	header.Set("X-App-Id", appID)
+	require.NoError(t, policy.Sign(http.MethodGet, uri, header))
+	signed := map[string]string{}
+	for _, k := range header.Keys() {
+		signed[k] = header.Get(k)
+	}
+	return signed
+}
+
+func TestMiddlewareGatewaySignature(t *testing.T) {
+	policy, err := NewPolicy(&Config{Secret: "gateway-secret"})
+	require.NoError(t, err)
+	server := newServer(t, policy)
+
+	signed := signedHeaders(t, policy, "/whoami", "app-1")
+	status, body := get(t, server.URL+"/whoami", signed)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "app-1", body)
+
+	// 签名绑定请求方法和 URI，不能用于其他接口或附加 Query 参数
+	status, body = get(t, server.URL+"/admin/whoami", signed)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, body)
+	_, body = get(t, server.URL+"/whoami?appId=app-2", signed)
+	assert.Empty(t, body)
+
+	// 篡改受保护的请求头后签名失效
+	signed["X-App-Id"] = "app-2"
+	status, body = get(t, server.URL+"/whoami", signed)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, body)
+
+	// 过期的签名
+	timeNow = func() time.Time { return time.Now().Add(-time.Hour) }
+	signed = signedHeaders(t, policy, "/whoami", "app-1")
+	timeNow = time.Now
+	status, body = get(t, server.URL+"/whoami", signed)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, body)
+}
+
+func TestMiddlewareGatewaySignatureReplay(t *testing.T) {
+	policy, err := NewPolicy(&Config{Secret: "gateway-secret"}, WithNonceStore(auth.NewMemoryNonceStore()))
+	require.NoError(t, err)
+	server := newServer(t, policy)
+
+	signed := signedHeaders(t, policy, "/whoami", "app-1")
+	_, body := get(t, server.URL+"/whoami", signed)
+	assert.Equal(t, "app-1", body)
+
+	// 同一签名只能使用一次
+	_, body = get(t, server.URL+"/whoami", signed)
+	assert.Empty(t, body)
+
+	// 配置 NonceStore 后必须携带 nonce
+	signed = signedHeaders(t, policy, "/whoami", "app-1")
+	delete(signed, "X-Gateway-Nonce")
+	_, body = get(t, server.URL+"/whoami", signed)
+	assert.Empty(t, body)
+}
+
+func TestMiddlewareStrict(t *testing.T) {
+	policy, err := NewPolicy(&Config{Strict: true})
+	require.NoError(t, err)
+	server := newServer(t, policy)
+
+	status, _ := get(t, server.URL+"/whoami", map[string]string{"X-End-User-Id": "u-1"})
+	assert.Equal(t, http.StatusForbidden, status)
+
+	// 未携带受保护字段的请求正常放行
+	status, _ = get(t, server.URL+"/whoami", nil)
+	assert.Equal(t, http.StatusOK, status)
+
+	ctx := authtest.ServerContext(context.Background(), "/op", map[string]string{"X-App-Id": "app-1"})
+	_, err = Middleware(policy, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})(ctx, nil)
+	assert.Equal(t, int32(pkgErrors.ErrCodeForbidden), kratosErrors.FromError(err).Code)
+}
+
+func TestMiddlewareGRPCMetadata(t *testing.T) {
+	policy, err := NewPolicy(&Config{TrustedProxies: []string{"10.0.0.1"}})
+	require.NoError(t, err)
+
+	handler := Middleware(policy, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return user_id.GetUserIDFromContext(ctx), nil
+	})
+	newCtx := func(ip string) context.Context {
+		ctx := authtest.ServerContext(context.Background(), "/op", map[string]string{"X-End-User-Id": "u-1"})
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-end-user-id", "u-1"))
+		return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 5000}})
+	}
+
+	out, err := handler(newCtx("10.0.0.1"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "u-1", out)
+
+	out, err = handler(newCtx("10.0.0.2"), nil)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestNewPolicyInvalidProxy(t *testing.T) {
+	_, err := NewPolicy(&Config{TrustedProxies: []string{"not-an-ip"}})
+	assert.Error(t, err)
+
+	policy, err := NewPolicy(&Config{TrustedProxies: []string{"192.168.1.10", "2001:db8::/32"}})
+	require.NoError(t, err)
+	assert.True(t, policy.TrustedAddr("192.168.1.10"))
+	assert.False(t, policy.TrustedAddr("192.168.1.11"))
+	assert.True(t, policy.TrustedAddr("2001:db8::1"))
+}
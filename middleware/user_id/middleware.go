@@ -5,27 +5,51 @@ import (
 	"context"
 	"strings"
 
+	"github.com/gaoyong06/go-pkg/middleware/idcheck"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
 	"google.golang.org/grpc/metadata"
 )
 
+// Option 终端用户 ID 中间件选项
+type Option func(*options)
+
+// options 终端用户 ID 中间件选项
+type options struct {
+	checker *idcheck.Checker
+}
+
+// Require 启用终端用户 ID 必填和格式校验，校验失败时返回本地化的业务错误（语法见 idcheck 包）
+// 例如：Middleware(Require(&idcheck.Config{RequirePaths: []string{"/v1/me/**"}, Format: idcheck.FormatNumeric}))
+func Require(config *idcheck.Config, opts ...idcheck.Option) Option {
+	return func(o *options) {
+		o.checker = idcheck.New("user_id", config, opts...)
+	}
+}
+
 // Middleware 终端用户 ID 中间件，提取终端用户 ID 并存入 context
 // 终端用户 ID 提取优先级：
 // 1. HTTP Header X-End-User-Id（由 API Gateway 的 jwt-user 插件设置）
 // 2. gRPC metadata X-End-User-Id（服务间调用时传递）
-func Middleware() middleware.Middleware {
+// 默认不要求必须携带终端用户 ID，需要时使用 Require 选项
+func Middleware(opts ...Option) middleware.Middleware {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
 			userID := extractUserID(ctx)
+			if err := o.checker.Check(ctx, userID); err != nil {
+				return nil, err
+			}
 			if userID != "" {
 				ctx = WithUserID(ctx, userID)
-				// 调试日志：记录成功提取的 userID
-				log.NewHelper(log.GetLogger()).Infof("user_id middleware: extracted userId=%s", userID)
 			} else {
-				// 调试日志：记录未找到 userID 的情况
-				log.NewHelper(log.GetLogger()).Infof("user_id middleware: no userId found in headers or metadata")
+				// 调试日志：记录未找到 userID 的情况（不记录用户 ID 本身）
+				log.NewHelper(log.GetLogger()).Debugf("user_id middleware: no userId found in headers or metadata")
 			}
 			return handler(ctx, req)
 		}